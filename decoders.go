@@ -0,0 +1,146 @@
+// Copyright 2017 tyranron
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envigo
+
+import (
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	refl "reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DecoderFunc decodes value of some type from given string.
+type DecoderFunc func(value string) (interface{}, error)
+
+// RegisterDecoder registers given decoder for values of given type, so they
+// can be parsed even if the type does not implement encoding.TextUnmarshaler
+// (like types of other packages). Decoder is used for struct fields of given
+// type, as well as for elements of arrays and slices, and keys and values of
+// maps, and takes priority over built-in parsing.
+// Decoder must return values assignable to given type.
+func (p *Parser) RegisterDecoder(typ refl.Type, fn DecoderFunc) {
+	decoders := make(map[refl.Type]DecoderFunc, len(p.decoders)+1)
+	for t, f := range p.decoders {
+		decoders[t] = f
+	}
+	decoders[typ] = fn
+	p.decoders = decoders
+}
+
+// RegisterFunc registers given function as a decoder of values of type T
+// for given Parser (see Parser.RegisterDecoder).
+func RegisterFunc[T any](p *Parser, fn func(value string) (T, error)) {
+	p.RegisterDecoder(refl.TypeOf((*T)(nil)).Elem(), decoderOf(fn))
+}
+
+// decoderOf wraps given function into DecoderFunc.
+func decoderOf[T any](fn func(value string) (T, error)) DecoderFunc {
+	return func(value string) (interface{}, error) {
+		return fn(value)
+	}
+}
+
+// builtinDecoders contains decoders of common standard library types, which
+// do not implement encoding.TextUnmarshaler.
+var builtinDecoders = map[refl.Type]DecoderFunc{
+	refl.TypeOf(url.URL{}):        decoderOf(parseURL),
+	refl.TypeOf(&url.URL{}):       decoderOf(url.Parse),
+	refl.TypeOf(&regexp.Regexp{}): decoderOf(regexp.Compile),
+	refl.TypeOf(&time.Location{}): decoderOf(time.LoadLocation),
+	refl.TypeOf(net.IPNet{}):      decoderOf(parseIPNet),
+	refl.TypeOf(&net.IPNet{}):     decoderOf(parseIPNetPtr),
+	refl.TypeOf(fs.FileMode(0)):   decoderOf(parseFileMode),
+}
+
+// parseURL parses url.URL from given string.
+func parseURL(value string) (url.URL, error) {
+	u, err := url.Parse(value)
+	if err != nil {
+		return url.URL{}, err
+	}
+	return *u, nil
+}
+
+// parseIPNet parses net.IPNet from given CIDR string (like `10.0.0.0/8`).
+func parseIPNet(value string) (net.IPNet, error) {
+	ipNet, err := parseIPNetPtr(value)
+	if err != nil {
+		return net.IPNet{}, err
+	}
+	return *ipNet, nil
+}
+
+// parseIPNetPtr parses *net.IPNet from given CIDR string (like `10.0.0.0/8`).
+func parseIPNetPtr(value string) (*net.IPNet, error) {
+	_, ipNet, err := net.ParseCIDR(value)
+	return ipNet, err
+}
+
+// parseFileMode parses fs.FileMode from given octal number (like `0644`,
+// `0o755` or `600`).
+func parseFileMode(value string) (fs.FileMode, error) {
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "0o"), "0O")
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil {
+		return 0, err
+	}
+	return fs.FileMode(mode), nil
+}
+
+// decoder returns decoder registered for given type, falling back to
+// the built-in one (if any).
+func (s *session) decoder(typ refl.Type) (DecoderFunc, bool) {
+	if decode, ok := s.decoders[typ]; ok {
+		return decode, true
+	}
+	decode, ok := builtinDecoders[typ]
+	return decode, ok
+}
+
+// hasDecoder checks whether decoder is registered (or built-in) for given
+// type.
+func (s *session) hasDecoder(typ refl.Type) bool {
+	_, ok := s.decoder(typ)
+	return ok
+}
+
+// parseWithDecoder tries to parse given string into given settable value
+// with decoder registered (or built-in) for its type.
+func (s *session) parseWithDecoder(val refl.Value, str string) (bool, error) {
+	decode, ok := s.decoder(val.Type())
+	if !ok {
+		return false, nil
+	}
+	res, err := decode(str)
+	if err != nil {
+		return true, err
+	}
+	if res == nil {
+		val.Set(refl.Zero(val.Type()))
+		return true, nil
+	}
+	v := refl.ValueOf(res)
+	if !v.Type().AssignableTo(val.Type()) {
+		return true, fmt.Errorf("decoder of %s returned value of %s type",
+			val.Type(), v.Type())
+	}
+	val.Set(v)
+	return true, nil
+}
@@ -0,0 +1,391 @@
+// Copyright 2017 tyranron
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dotenv implements reading of env vars from `.env` files,
+// which can be used as a source of envigo.Parser.
+//
+// Files are expected to be in the usual dotenv format:
+//
+//	# comment
+//	export HOST=localhost    # `export` prefix is optional
+//	PORT = 8080
+//	NAME='raw $value'        # single quotes keep value as is
+//	GREETING="Hello,\n\"${NAME}\"!"
+//	KEY="-----BEGIN KEY-----
+//	multiline value
+//	-----END KEY-----"
+//
+// Double-quoted values support `\n`, `\r`, `\t`, `\"`, `\\` and `\$` escape
+// sequences. Both unquoted and double-quoted values are interpolated:
+// `${VAR}` and `$VAR` are replaced with the value of env var, which is
+// defined earlier in the same file (or is set in process environment).
+package dotenv
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Env is a set of env vars read from `.env` files.
+type Env map[string]string
+
+// Read reads env vars from given `.env` files. Env vars of later files
+// override the ones of earlier files.
+func Read(filenames ...string) (Env, error) {
+	env := Env{}
+	for _, filename := range filenames {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, err
+		}
+		err = env.read(f, filename)
+		f.Close() // nolint: errcheck
+		if err != nil {
+			return nil, err
+		}
+	}
+	return env, nil
+}
+
+// Parse reads env vars from given reader in `.env` file format.
+func Parse(r io.Reader) (Env, error) {
+	env := Env{}
+	if err := env.read(r, ""); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// Lookup retrieves the value of env var with given name read from `.env`
+// files only, reporting whether it is set or not.
+// It can be used as envigo.Parser.LookupEnv.
+func (e Env) Lookup(name string) (string, bool) {
+	val, ok := e[name]
+	return val, ok
+}
+
+// LookupEnv retrieves the value of env var with given name, reporting
+// whether it is set or not. Env vars set in process environment take
+// precedence over the ones read from `.env` files, so the latter can be
+// overridden as usual.
+// It can be used as envigo.Parser.LookupEnv.
+func (e Env) LookupEnv(name string) (string, bool) {
+	if val, ok := os.LookupEnv(name); ok {
+		return val, ok
+	}
+	return e.Lookup(name)
+}
+
+// Unset removes env var with given name from env vars read from `.env`
+// files. It never fails.
+// It can be used as envigo.Parser.UnsetEnv along with Lookup.
+func (e Env) Unset(name string) error {
+	delete(e, name)
+	return nil
+}
+
+// Unsetenv removes env var with given name both from process environment
+// and from env vars read from `.env` files.
+// It can be used as envigo.Parser.UnsetEnv along with LookupEnv.
+func (e Env) Unsetenv(name string) error {
+	delete(e, name)
+	return os.Unsetenv(name)
+}
+
+// Environ lists env vars read from `.env` files in the form "name=value".
+// It can be used as envigo.Parser.Environ along with Lookup. To be used
+// along with LookupEnv, it should be combined with os.Environ:
+//
+//	func() []string { return append(os.Environ(), env.Environ()...) }
+func (e Env) Environ() []string {
+	environ := make([]string, 0, len(e))
+	for name, val := range e {
+		environ = append(environ, name+"="+val)
+	}
+	sort.Strings(environ)
+	return environ
+}
+
+// SyntaxError occurs when `.env` file is malformed.
+type SyntaxError struct {
+	// File is a name of malformed file (empty if read by Parse).
+	File string
+	// Line is a number of line (starting from 1), where error occurs.
+	Line int
+	// Msg describes what is wrong.
+	Msg string
+}
+
+// Error returns string representation of syntax error.
+func (e SyntaxError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("dotenv: line %d: %s", e.Line, e.Msg)
+	}
+	return fmt.Sprintf("dotenv: %s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+// read reads env vars from given reader into this Env.
+func (e Env) read(r io.Reader, filename string) error {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	p := &parser{src: string(src), env: e}
+	err = p.parse()
+	if synErr, ok := err.(SyntaxError); ok {
+		synErr.File = filename
+		return synErr
+	}
+	return err
+}
+
+// parser holds the state of a single `.env` file parsing.
+type parser struct {
+	src string
+	pos int
+	env Env
+}
+
+// parse parses the whole source, storing env vars into Env.
+func (p *parser) parse() error {
+	for {
+		p.skip(" \t\r\n")
+		if p.eof() {
+			return nil
+		}
+		if p.peek() == '#' {
+			p.skipLine()
+			continue
+		}
+		if strings.HasPrefix(p.src[p.pos:], "export") {
+			rest := p.src[p.pos+len("export"):]
+			if rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+				p.pos += len("export")
+				p.skip(" \t")
+			}
+		}
+		name := p.name()
+		if name == "" {
+			return p.errorf("expected env var name")
+		}
+		p.skip(" \t")
+		if p.eof() || p.peek() != '=' {
+			return p.errorf("expected '=' after '%s'", name)
+		}
+		p.pos++
+		p.skip(" \t")
+		val, err := p.value()
+		if err != nil {
+			return err
+		}
+		p.env[name] = val
+	}
+}
+
+// value parses env var value, which may be quoted.
+func (p *parser) value() (string, error) {
+	if p.eof() {
+		return "", nil
+	}
+	var (
+		val string
+		err error
+	)
+	switch p.peek() {
+	case '\'':
+		val, err = p.singleQuoted()
+	case '"':
+		val, err = p.doubleQuoted()
+	default:
+		return p.unquoted()
+	}
+	if err != nil {
+		return "", err
+	}
+	p.skip(" \t")
+	if !p.eof() && p.peek() != '\n' && p.peek() != '\r' && p.peek() != '#' {
+		return "", p.errorf("unexpected character after quoted value")
+	}
+	p.skipLine()
+	return val, nil
+}
+
+// singleQuoted parses single-quoted value as is.
+func (p *parser) singleQuoted() (string, error) {
+	start := p.pos
+	end := strings.IndexByte(p.src[start+1:], '\'')
+	if end < 0 {
+		return "", p.errorf("unterminated single-quoted value")
+	}
+	p.pos = start + 1 + end + 1
+	return p.src[start+1 : start+1+end], nil
+}
+
+// doubleQuoted parses double-quoted value, unescaping and interpolating it.
+func (p *parser) doubleQuoted() (string, error) {
+	start := p.pos
+	p.pos++
+	var b strings.Builder
+	for {
+		if p.eof() {
+			p.pos = start
+			return "", p.errorf("unterminated double-quoted value")
+		}
+		c := p.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return b.String(), nil
+		case '\\':
+			if p.pos+1 >= len(p.src) {
+				p.pos++
+				continue
+			}
+			p.pos += 2
+			switch e := p.src[p.pos-1]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '$':
+				b.WriteByte(e)
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(e)
+			}
+		case '$':
+			if err := p.interpolate(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// unquoted parses unquoted value till the end of line, omitting trailing
+// comment and whitespaces, and interpolating it.
+func (p *parser) unquoted() (string, error) {
+	var b strings.Builder
+	for !p.eof() {
+		c := p.src[p.pos]
+		if c == '\n' {
+			break
+		}
+		if c == '#' && p.pos > 0 &&
+			(p.src[p.pos-1] == ' ' || p.src[p.pos-1] == '\t') {
+			p.skipLine()
+			break
+		}
+		if c == '$' {
+			if err := p.interpolate(&b); err != nil {
+				return "", err
+			}
+			continue
+		}
+		b.WriteByte(c)
+		p.pos++
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// interpolate parses `${VAR}` or `$VAR` reference, writing the value of
+// referenced env var into given builder. Lone `$` is written as is.
+func (p *parser) interpolate(b *strings.Builder) error {
+	start := p.pos
+	p.pos++
+	var name string
+	if !p.eof() && p.peek() == '{' {
+		end := strings.IndexByte(p.src[p.pos:], '}')
+		if end < 0 {
+			p.pos = start
+			return p.errorf("unterminated '${' reference")
+		}
+		name = p.src[p.pos+1 : p.pos+end]
+		p.pos += end + 1
+	} else if name = p.refName(); name == "" {
+		b.WriteByte('$')
+		return nil
+	}
+	if val, ok := p.env[name]; ok {
+		b.WriteString(val)
+	} else {
+		b.WriteString(os.Getenv(name))
+	}
+	return nil
+}
+
+// name parses env var name.
+func (p *parser) name() string {
+	start := p.pos
+	for !p.eof() && (isNameChar(p.peek()) || p.peek() == '.') {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// refName parses env var name of `$VAR` reference, which cannot contain
+// dots, so `$HOST.local` refers to `HOST`.
+func (p *parser) refName() string {
+	start := p.pos
+	for !p.eof() && isNameChar(p.peek()) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// isNameChar checks whether given character may be a part of env var name
+// (except dots, which are allowed in names of defined env vars only).
+func isNameChar(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') ||
+		('0' <= c && c <= '9')
+}
+
+// eof checks whether the whole source has been parsed.
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+// peek returns current character of the source.
+func (p *parser) peek() byte {
+	return p.src[p.pos]
+}
+
+// skip skips all consecutive characters of given set.
+func (p *parser) skip(chars string) {
+	for !p.eof() && strings.IndexByte(chars, p.peek()) >= 0 {
+		p.pos++
+	}
+}
+
+// skipLine skips the rest of current line.
+func (p *parser) skipLine() {
+	for !p.eof() && p.peek() != '\n' {
+		p.pos++
+	}
+}
+
+// errorf creates SyntaxError at current position of the source.
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return SyntaxError{
+		Line: strings.Count(p.src[:p.pos], "\n") + 1,
+		Msg:  fmt.Sprintf(format, args...),
+	}
+}
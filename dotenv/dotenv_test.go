@@ -0,0 +1,152 @@
+// Copyright 2017 tyranron
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dotenv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParse(t *testing.T) {
+	Convey("Parse()", t, func() {
+		Convey("Parses dotenv syntax", func() {
+			os.Setenv("DOTENV_OS_VAR", "os")
+			env, err := Parse(strings.NewReader(`
+# comment
+export HOST=localhost
+PORT = 8080 # inline comment
+EMPTY=
+HASH=a#b
+RAW='raw $HOST \n'
+QUOTED="Hello,\t\"$HOST\"!\n\$HOST"
+MULTI="line1
+line2"
+MULTI_RAW='line1
+line2' # comment
+URL=http://${HOST}:${PORT}/${DOTENV_OS_VAR}
+PRICE=$ 5
+app.host=$HOST.local
+`))
+
+			So(err, ShouldBeNil)
+			So(env, ShouldResemble, Env{
+				"HOST":      "localhost",
+				"PORT":      "8080",
+				"EMPTY":     "",
+				"HASH":      "a#b",
+				"RAW":       `raw $HOST \n`,
+				"QUOTED":    "Hello,\t\"localhost\"!\n$HOST",
+				"MULTI":     "line1\nline2",
+				"MULTI_RAW": "line1\nline2",
+				"URL":       "http://localhost:8080/os",
+				"PRICE":     "$ 5",
+				"app.host":  "localhost.local",
+			})
+		})
+
+		Convey("Returns error on malformed input", func() {
+			for src, line := range map[string]int{
+				"=value":                  1,
+				"\nNAME value":            2,
+				"A=1\nB=\"unterminated\n": 2,
+				"A='unterminated":         1,
+				"A='x' y":                 1,
+				"A=${B":                   1,
+			} {
+				_, err := Parse(strings.NewReader(src))
+
+				So(err, ShouldHaveSameTypeAs, SyntaxError{})
+				So(err.(SyntaxError).Line, ShouldEqual, line)
+			}
+		})
+	})
+}
+
+func TestRead(t *testing.T) {
+	Convey("Read()", t, func() {
+		dir, err := os.MkdirTemp("", "dotenv")
+		So(err, ShouldBeNil)
+		Reset(func() { os.RemoveAll(dir) })
+		file1 := filepath.Join(dir, ".env")
+		file2 := filepath.Join(dir, ".env.local")
+		So(os.WriteFile(file1, []byte("A=1\nB=2\n"), 0600), ShouldBeNil)
+		So(os.WriteFile(file2, []byte("B=3\nC=\"x"), 0600), ShouldBeNil)
+
+		Convey("Overrides env vars of earlier files", func() {
+			So(os.WriteFile(file2, []byte("B=3\n"), 0600), ShouldBeNil)
+			env, err := Read(file1, file2)
+
+			So(err, ShouldBeNil)
+			So(env, ShouldResemble, Env{"A": "1", "B": "3"})
+		})
+
+		Convey("Reports file name of malformed file", func() {
+			_, err := Read(file1, file2)
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, file2+":2")
+		})
+
+		Convey("Returns error if file cannot be opened", func() {
+			_, err := Read(filepath.Join(dir, "missing"))
+
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestEnv_LookupEnv(t *testing.T) {
+	Convey("Prefers process env vars", t, func() {
+		os.Setenv("DOTENV_LOOKUP", "os")
+		env := Env{"DOTENV_LOOKUP": "file", "DOTENV_ONLY": "file"}
+
+		val, ok := env.LookupEnv("DOTENV_LOOKUP")
+		So(ok, ShouldBeTrue)
+		So(val, ShouldEqual, "os")
+		val, ok = env.LookupEnv("DOTENV_ONLY")
+		So(ok, ShouldBeTrue)
+		So(val, ShouldEqual, "file")
+		val, ok = env.Lookup("DOTENV_LOOKUP")
+		So(ok, ShouldBeTrue)
+		So(val, ShouldEqual, "file")
+	})
+}
+
+func TestEnv_Unsetenv(t *testing.T) {
+	Convey("Removes env vars from all sources", t, func() {
+		os.Setenv("DOTENV_UNSET", "os")
+		env := Env{"DOTENV_UNSET": "file", "DOTENV_KEEP": "file"}
+
+		So(env.Unsetenv("DOTENV_UNSET"), ShouldBeNil)
+		_, ok := env.LookupEnv("DOTENV_UNSET")
+		So(ok, ShouldBeFalse)
+		So(env, ShouldResemble, Env{"DOTENV_KEEP": "file"})
+
+		So(env.Unset("DOTENV_KEEP"), ShouldBeNil)
+		So(env, ShouldBeEmpty)
+	})
+}
+
+func TestEnv_Environ(t *testing.T) {
+	Convey("Lists env vars in 'name=value' form", t, func() {
+		env := Env{"B": "2=2", "A": "1"}
+
+		So(env.Environ(), ShouldResemble, []string{"A=1", "B=2=2"})
+	})
+}
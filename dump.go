@@ -0,0 +1,194 @@
+// Copyright 2017 tyranron
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envigo
+
+import (
+	"encoding"
+	"fmt"
+	refl "reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Dump performs dumping with default parser.
+func Dump(obj interface{}) string {
+	return Parser{}.Dump(obj)
+}
+
+// Dump returns string representation of given struct (or pointer to it),
+// which is safe to be logged. It lists values of all struct fields, which
+// are parsed by Parse, one per line in `Field.Path: value` form (nested
+// structs and their collections are listed field by field).
+// Values of struct fields tagged with `secret` option (like
+// `env:"DB_PASSWORD,secret"`) are masked, while string values are quoted.
+// It can be used to implement fmt.Stringer for config structs:
+//
+//	func (c Config) String() string { return envigo.Dump(c) }
+func (p Parser) Dump(obj interface{}) string {
+	val := refl.ValueOf(obj)
+	for val.Kind() == refl.Ptr && !val.IsNil() {
+		val = val.Elem()
+	}
+	if val.Kind() != refl.Struct {
+		return fmt.Sprint(obj)
+	}
+	// Copy struct to make its fields addressable
+	structVal := refl.New(val.Type()).Elem()
+	structVal.Set(val)
+
+	s := &session{Parser: p}
+	var lines []string
+	s.dumpStruct(&lines, structVal, "")
+	return strings.Join(lines, "\n")
+}
+
+// secretMask replaces values of secret struct fields in Dump.
+const secretMask = "<redacted>"
+
+// dumpStruct appends lines of given struct, which is located by given path,
+// to given lines in the same order as its fields are parsed.
+func (s *session) dumpStruct(
+	lines *[]string, structVal refl.Value, path string,
+) {
+	structType := structVal.Type()
+	for i := 0; i < structVal.NumField(); i++ {
+		fieldVal := structVal.Field(i)
+
+		// Omit private field
+		if !fieldVal.CanSet() {
+			continue
+		}
+
+		envTag, hasTag := structType.Field(i).Tag.Lookup(s.tagName())
+		// Omit explicitly skipped field
+		if envTag == "-" {
+			continue
+		}
+
+		fieldPath := joinPath(path, structType.Field(i).Name)
+		envName, envOpts := parseEnvTag(envTag)
+		nested := s.isNestedStruct(structType.Field(i).Type)
+		if nested && envName == "" &&
+			(envOpts.Has("inline") || envOpts.Has("squash")) {
+			hasTag = false
+		}
+		if s.AutoNames != nil && envName == "" && (hasTag || !nested) {
+			hasTag = true
+		}
+
+		switch {
+		case hasTag && envOpts.Has("secret"):
+			*lines = append(*lines, fieldPath+": "+secretMask)
+		case hasTag && s.isStructCollection(structType.Field(i).Type):
+			s.dumpStructs(lines, fieldVal, fieldPath)
+		case hasTag:
+			*lines = append(*lines, fieldPath+": "+formatValue(fieldVal))
+		case nested:
+			for fieldVal.Kind() == refl.Ptr && !fieldVal.IsNil() {
+				fieldVal = fieldVal.Elem()
+			}
+			if fieldVal.Kind() == refl.Ptr {
+				*lines = append(*lines, fieldPath+": <nil>")
+				continue
+			}
+			s.dumpStruct(lines, fieldVal, fieldPath)
+		}
+	}
+}
+
+// dumpStructs appends lines of given array, slice or map of structs (or
+// pointers to them), which is located by given path, to given lines.
+// Elements are listed by their indices (or keys sorted as strings).
+func (s *session) dumpStructs(lines *[]string, val refl.Value, path string) {
+	type elem struct {
+		path string
+		val  refl.Value
+	}
+	var elems []elem
+	if val.Kind() == refl.Map {
+		for _, key := range val.MapKeys() {
+			// Copy map value to make its fields addressable
+			v := refl.New(val.Type().Elem()).Elem()
+			v.Set(val.MapIndex(key))
+			elemPath := fmt.Sprintf("%s[%v]", path, key)
+			elems = append(elems, elem{elemPath, v})
+		}
+		sort.Slice(elems, func(i, j int) bool {
+			return elems[i].path < elems[j].path
+		})
+	} else {
+		for i := 0; i < val.Len(); i++ {
+			elemPath := fmt.Sprintf("%s[%d]", path, i)
+			elems = append(elems, elem{elemPath, val.Index(i)})
+		}
+	}
+	for _, e := range elems {
+		structVal := e.val
+		for structVal.Kind() == refl.Ptr && !structVal.IsNil() {
+			structVal = structVal.Elem()
+		}
+		if structVal.Kind() == refl.Ptr {
+			*lines = append(*lines, e.path+": <nil>")
+			continue
+		}
+		s.dumpStruct(lines, structVal, e.path)
+	}
+}
+
+// formatValue returns string representation of given struct field value.
+// Values implementing encoding.TextMarshaler or fmt.Stringer (possibly
+// behind pointers) are represented by their text, strings are quoted, while
+// other values are formatted with fmt.
+func formatValue(val refl.Value) string {
+	for {
+		if val.Kind() == refl.Ptr && val.IsNil() {
+			return "<nil>"
+		}
+		if text, ok := textOf(val); ok {
+			return text
+		}
+		if val.Kind() != refl.Ptr {
+			break
+		}
+		val = val.Elem()
+	}
+	if val.Kind() == refl.String {
+		return strconv.Quote(val.String())
+	}
+	return fmt.Sprint(val.Interface())
+}
+
+// textOf returns text of given value, if it (or pointer to it) implements
+// encoding.TextMarshaler or fmt.Stringer.
+func textOf(val refl.Value) (string, bool) {
+	vals := []refl.Value{val}
+	if val.CanAddr() {
+		vals = append(vals, val.Addr())
+	}
+	for _, v := range vals {
+		if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+			if text, err := m.MarshalText(); err == nil {
+				return string(text), true
+			}
+		}
+	}
+	for _, v := range vals {
+		if m, ok := v.Interface().(fmt.Stringer); ok {
+			return m.String(), true
+		}
+	}
+	return "", false
+}
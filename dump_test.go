@@ -0,0 +1,93 @@
+// Copyright 2017 tyranron
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envigo
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDump(t *testing.T) {
+	type DB struct {
+		URL      *url.URL `env:"URL"`
+		Password string   `env:"PASSWORD,secret"`
+	}
+	type Config struct {
+		Host     string        `env:"HOST"`
+		Timeout  time.Duration `env:"TIMEOUT"`
+		Tags     []string      `env:"TAGS"`
+		Token    string        `env:"TOKEN,secret"`
+		Skipped  string        `env:"-"`
+		Untagged int
+		private  int
+		DB       DB    `envPrefix:"DB_"`
+		Replicas []*DB `env:"REPLICA"`
+		Caches   map[string]struct {
+			Size ByteSize `env:"SIZE"`
+		} `env:"CACHE"`
+		Next *DB
+	}
+	dbURL, _ := url.Parse("postgres://db")
+	conf := Config{
+		Host:     "localhost",
+		Timeout:  5 * time.Second,
+		Tags:     []string{"a", "b"},
+		Token:    "s3cr3t",
+		Skipped:  "skipped",
+		Untagged: 1,
+		private:  2,
+		DB:       DB{URL: dbURL, Password: "p4ss"},
+		Replicas: []*DB{{Password: "p4ss"}, nil},
+		Caches: map[string]struct {
+			Size ByteSize `env:"SIZE"`
+		}{"users": {Size: 1024}, "tokens": {Size: 512}},
+	}
+
+	Convey("Lists parsed fields with secrets masked", t, func() {
+		So(Dump(conf), ShouldEqual, `Host: "localhost"
+Timeout: 5s
+Tags: [a b]
+Token: <redacted>
+DB.URL: postgres://db
+DB.Password: <redacted>
+Replicas[0].URL: <nil>
+Replicas[0].Password: <redacted>
+Replicas[1]: <nil>
+Caches[tokens].Size: 512
+Caches[users].Size: 1024
+Next: <nil>`)
+		So(Dump(&conf), ShouldEqual, Dump(conf))
+	})
+
+	Convey("Lists untagged fields if AutoNames is set", t, func() {
+		obj := struct {
+			Host  string `env:",secret"`
+			Port  int
+			Debug *bool
+		}{Host: "localhost", Port: 8080}
+
+		So(Parser{AutoNames: ScreamingSnakeNames}.Dump(obj), ShouldEqual,
+			"Host: <redacted>\nPort: 8080\nDebug: <nil>")
+		So(Dump(obj), ShouldEqual, "Host: <redacted>")
+	})
+
+	Convey("Formats non-struct values as is", t, func() {
+		So(Dump(42), ShouldEqual, "42")
+		So(Dump((*struct{})(nil)), ShouldEqual, "<nil>")
+	})
+}
@@ -17,6 +17,9 @@ package envigo
 import (
 	"errors"
 	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
 )
 
 // ErrNotStructPtr occurs when an object passed to Parse() method is not
@@ -46,16 +49,237 @@ func (e UnparsableTypeError) Error() string {
 		"envigo: type of field '%s' is not parsable from string", e.Field)
 }
 
+// InvalidTagError occurs when struct field tag has a value, which cannot
+// be used (like a malformed validation rule).
+type InvalidTagError struct {
+	Field string
+	// Tag is a name of struct field tag (or `env` tag option).
+	Tag string
+	// Err describes why the value of tag cannot be used.
+	Err error
+}
+
+// Error returns string representation of invalid struct field tag error.
+func (e InvalidTagError) Error() string {
+	return fmt.Sprintf("envigo: invalid '%s' tag on field '%s': %v",
+		e.Tag, e.Field, e.Err)
+}
+
+// Unwrap returns the reason why struct field tag cannot be used.
+func (e InvalidTagError) Unwrap() error {
+	return e.Err
+}
+
 // ParseError occurs when parsing from env var value fails.
 type ParseError struct {
-	Field  string
+	// Field is a dot-separated path to struct field (like `Server.TLS.Cert`).
+	Field string
+	// EnvVar is a name of env var, which value failed to parse.
 	EnvVar string
-	reason string
+	// Value is a raw value of env var, which failed to parse.
+	// It is empty if values are redacted (see Parser.RedactValues).
+	Value string
+	// Err is an underlying cause of parsing failure.
+	Err error
 }
 
 // Error returns string representation of parsing error.
 func (e ParseError) Error() string {
 	return fmt.Sprintf(
-		"envigo: field '%s' failed to parse from '%s' env var: %s",
-		e.Field, e.EnvVar, e.reason)
+		"envigo: field '%s' failed to parse from '%s' env var: %v",
+		e.Field, e.EnvVar, e.Err)
+}
+
+// Unwrap returns underlying cause of parsing error.
+func (e ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ElementError occurs when parsing of a single element of array or slice
+// (or a single entry of map) fails. It is reported as an underlying cause
+// of ParseError.
+type ElementError struct {
+	// Index is a position of element in env var value (starting from 0).
+	Index int
+	// Value is a raw value of element, which failed to parse.
+	// It is empty if values are redacted (see Parser.RedactValues).
+	Value string
+	// Err is an underlying cause of element parsing failure.
+	Err error
+}
+
+// Error returns string representation of element parsing error.
+func (e ElementError) Error() string {
+	return fmt.Sprintf("element [%d] failed to parse: %v", e.Index, e.Err)
+}
+
+// Unwrap returns underlying cause of element parsing error.
+func (e ElementError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError occurs when parsed value of struct field violates its
+// validation rule (like `min` or `oneof`).
+type ValidationError struct {
+	// Field is a dot-separated path to struct field (like `Server.Port`).
+	Field string
+	// EnvVar is a name of env var, which value is invalid.
+	EnvVar string
+	// Rule is a name of violated validation rule.
+	Rule string
+	// Err describes the violation. It never contains the invalid value.
+	Err error
+}
+
+// Error returns string representation of validation error.
+func (e ValidationError) Error() string {
+	return fmt.Sprintf(
+		"envigo: field '%s' parsed from '%s' env var is invalid: %v",
+		e.Field, e.EnvVar, e.Err)
+}
+
+// Unwrap returns the description of validation rule violation.
+func (e ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// StructValidationError occurs when Validate() method of parsed struct
+// (see Validator) returns error.
+type StructValidationError struct {
+	// Struct is a dot-separated path to struct (like `Server.TLS`),
+	// which is empty for the parsed struct itself.
+	Struct string
+	// Err is an error returned by Validate() method.
+	Err error
+}
+
+// Error returns string representation of struct validation error.
+func (e StructValidationError) Error() string {
+	if e.Struct == "" {
+		return fmt.Sprintf("envigo: validation failed: %v", e.Err)
+	}
+	return fmt.Sprintf(
+		"envigo: validation of struct '%s' failed: %v", e.Struct, e.Err)
+}
+
+// Unwrap returns an error returned by Validate() method.
+func (e StructValidationError) Unwrap() error {
+	return e.Err
+}
+
+// redactedError replaces a failure of parsing env var value, as its
+// description (and the failure itself) may contain the raw value. Only
+// a sentinel cause of the failure (like strconv.ErrSyntax) is kept.
+type redactedError struct {
+	err error
+}
+
+// redactedCauses lists sentinel causes, which are kept by redactedError.
+var redactedCauses = []error{
+	errNotInteger, errOutOfRange, errUnterminatedRef, errInvalidRef,
+	fs.ErrNotExist, fs.ErrPermission,
+}
+
+// redact creates redactedError for given failure of parsing env var value.
+func redact(err error) redactedError {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		return redactedError{numErr.Err}
+	}
+	for _, cause := range redactedCauses {
+		if errors.Is(err, cause) {
+			return redactedError{cause}
+		}
+	}
+	return redactedError{}
+}
+
+// Error returns string representation of redacted failure.
+func (e redactedError) Error() string {
+	if e.err == nil {
+		return "<redacted> value is invalid"
+	}
+	return "<redacted> value is invalid: " + e.err.Error()
+}
+
+// Unwrap returns sentinel cause of redacted failure, if any.
+func (e redactedError) Unwrap() error {
+	return e.err
+}
+
+// MissingVarError occurs when env vars marked as `required` are not set.
+type MissingVarError struct {
+	Vars []MissingVar
+}
+
+// MissingVar describes required env var that is not set.
+type MissingVar struct {
+	// Field is a dot-separated path to struct field (like `DB.URL`).
+	Field string
+	// EnvVar is a name of missing env var.
+	EnvVar string
+}
+
+// Error returns string representation of missing env vars error.
+func (e MissingVarError) Error() string {
+	vars := make([]string, 0, len(e.Vars))
+	for _, v := range e.Vars {
+		vars = append(vars, fmt.Sprintf("'%s' (field '%s')", v.EnvVar, v.Field))
+	}
+	return "envigo: required env vars are not set: " + strings.Join(vars, ", ")
+}
+
+// UnknownVarError occurs when env vars with parser prefix, which are not used
+// by any struct field, are set (see Parser.Strict).
+type UnknownVarError struct {
+	Vars []UnknownVar
+}
+
+// UnknownVar describes env var, which is not used by any struct field.
+type UnknownVar struct {
+	// EnvVar is a name of unknown env var.
+	EnvVar string
+	// Suggestion is a name of known env var, which is the most similar
+	// to unknown one (like in case of typo). It is empty if there is no
+	// similar enough env var.
+	Suggestion string
+}
+
+// Error returns string representation of unknown env vars error.
+func (e UnknownVarError) Error() string {
+	vars := make([]string, 0, len(e.Vars))
+	for _, v := range e.Vars {
+		if v.Suggestion == "" {
+			vars = append(vars, fmt.Sprintf("'%s'", v.EnvVar))
+		} else {
+			vars = append(vars, fmt.Sprintf(
+				"'%s' (did you mean '%s'?)", v.EnvVar, v.Suggestion))
+		}
+	}
+	return "envigo: unknown env vars are set: " + strings.Join(vars, ", ")
+}
+
+// ParseErrors occurs when parsing from env var values fails for several
+// struct fields, and parser is configured to collect all such failures
+// (see Parser.CollectErrors).
+type ParseErrors []ParseError
+
+// Error returns string representation of multiple parsing errors.
+func (e ParseErrors) Error() string {
+	errs := make([]string, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, strings.TrimPrefix(err.Error(), "envigo: "))
+	}
+	return fmt.Sprintf("envigo: failed to parse %d field(s): %s",
+		len(e), strings.Join(errs, "; "))
+}
+
+// Unwrap returns all the parsing errors, so they can be inspected with
+// errors.Is() and errors.As().
+func (e ParseErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, err := range e {
+		errs = append(errs, err)
+	}
+	return errs
 }
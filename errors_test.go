@@ -15,6 +15,8 @@
 package envigo
 
 import (
+	"errors"
+	"strconv"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
@@ -36,22 +38,188 @@ func TestUnparsableTypeError_Error(t *testing.T) {
 	})
 }
 
+func TestInvalidTagError_Error(t *testing.T) {
+	Convey("Contains struct field and tag names", t, func() {
+		err := InvalidTagError{Field: "fld", Tag: "min", Err: errors.New("")}
+
+		So(err.Error(), ShouldContainSubstring, "'fld'")
+		So(err.Error(), ShouldContainSubstring, "'min'")
+	})
+}
+
 func TestParseError_Error(t *testing.T) {
 	Convey("Contains struct field name", t, func() {
-		err := ParseError{"f1eld", "", ""}
+		err := ParseError{Field: "f1eld", Err: errors.New("")}
 
 		So(err.Error(), ShouldContainSubstring, "'f1eld'")
 	})
 
 	Convey("Contains env var name", t, func() {
-		err := ParseError{"", "ENV_VAR", ""}
+		err := ParseError{EnvVar: "ENV_VAR", Err: errors.New("")}
 
 		So(err.Error(), ShouldContainSubstring, "'ENV_VAR'")
 	})
 
 	Convey("Contains error reason", t, func() {
-		err := ParseError{"", "", "some reason here"}
+		err := ParseError{Err: errors.New("some reason here")}
+
+		So(err.Error(), ShouldContainSubstring, "some reason here")
+	})
+
+	Convey("Masks redacted value", t, func() {
+		err := ParseError{Err: redact(errors.New(`parsing "secret": invalid`))}
+
+		So(err.Error(), ShouldNotContainSubstring, "secret")
+		So(err.Error(), ShouldContainSubstring, "<redacted> value is invalid")
+	})
+}
+
+func TestParseError_Unwrap(t *testing.T) {
+	Convey("Returns underlying cause", t, func() {
+		cause := errors.New("cause")
+		err := ParseError{Err: cause}
+
+		So(err.Unwrap(), ShouldEqual, cause)
+		So(errors.Is(err, cause), ShouldBeTrue)
+	})
+
+	Convey("Returns only sentinel cause of redacted value", t, func() {
+		_, cause := strconv.Atoi("s3cr3t")
+		err := ParseError{Err: redact(cause)}
+
+		So(errors.Is(err, strconv.ErrSyntax), ShouldBeTrue)
+		var numErr *strconv.NumError
+		So(errors.As(err, &numErr), ShouldBeFalse)
+		So(err.Error(), ShouldNotContainSubstring, "s3cr3t")
+
+		err = ParseError{Err: redact(errors.New("s3cr3t"))}
+		So(err.Unwrap().(redactedError).Unwrap(), ShouldBeNil)
+	})
+}
+
+func TestValidationError_Error(t *testing.T) {
+	Convey("Contains struct field and env var names", t, func() {
+		err := ValidationError{
+			Field: "f1eld", EnvVar: "ENV_VAR", Err: errors.New("too big"),
+		}
+
+		So(err.Error(), ShouldContainSubstring, "'f1eld'")
+		So(err.Error(), ShouldContainSubstring, "'ENV_VAR'")
+		So(err.Error(), ShouldContainSubstring, "too big")
+	})
+}
+
+func TestValidationError_Unwrap(t *testing.T) {
+	Convey("Returns violation description", t, func() {
+		cause := errors.New("too big")
+		err := ValidationError{Err: cause}
+
+		So(errors.Is(err, cause), ShouldBeTrue)
+	})
+}
+
+func TestStructValidationError_Error(t *testing.T) {
+	Convey("Contains struct path and error reason", t, func() {
+		err := StructValidationError{
+			Struct: "Server.TLS", Err: errors.New("key required"),
+		}
+
+		So(err.Error(), ShouldContainSubstring, "'Server.TLS'")
+		So(err.Error(), ShouldContainSubstring, "key required")
+	})
+
+	Convey("Omits empty struct path", t, func() {
+		err := StructValidationError{Err: errors.New("key required")}
+
+		So(err.Error(), ShouldNotContainSubstring, "''")
+	})
+}
+
+func TestStructValidationError_Unwrap(t *testing.T) {
+	Convey("Returns error of Validate() method", t, func() {
+		cause := errors.New("key required")
+		err := StructValidationError{Err: cause}
+
+		So(errors.Is(err, cause), ShouldBeTrue)
+	})
+}
+
+func TestMissingVarError_Error(t *testing.T) {
+	Convey("Contains all struct field paths and env var names", t, func() {
+		err := MissingVarError{[]MissingVar{
+			{Field: "N.F1", EnvVar: "VAR_1"},
+			{Field: "F2", EnvVar: "VAR_2"},
+		}}
+
+		So(err.Error(), ShouldContainSubstring, "'N.F1'")
+		So(err.Error(), ShouldContainSubstring, "'VAR_1'")
+		So(err.Error(), ShouldContainSubstring, "'F2'")
+		So(err.Error(), ShouldContainSubstring, "'VAR_2'")
+	})
+}
+
+func TestUnknownVarError_Error(t *testing.T) {
+	Convey("Contains all env vars and suggestions", t, func() {
+		err := UnknownVarError{[]UnknownVar{
+			{EnvVar: "APP_HOTS", Suggestion: "APP_HOST"},
+			{EnvVar: "APP_DEBUG"},
+		}}
+
+		So(err.Error(), ShouldContainSubstring,
+			"'APP_HOTS' (did you mean 'APP_HOST'?)")
+		So(err.Error(), ShouldContainSubstring, "'APP_DEBUG'")
+	})
+}
+
+func TestElementError_Error(t *testing.T) {
+	Convey("Contains element index", t, func() {
+		err := ElementError{Index: 3, Err: errors.New("")}
+
+		So(err.Error(), ShouldContainSubstring, "[3]")
+	})
+
+	Convey("Contains error reason", t, func() {
+		err := ElementError{Err: errors.New("some reason here")}
 
 		So(err.Error(), ShouldContainSubstring, "some reason here")
 	})
 }
+
+func TestElementError_Unwrap(t *testing.T) {
+	Convey("Returns underlying cause", t, func() {
+		cause := errors.New("cause")
+		err := ElementError{Err: cause}
+
+		So(err.Unwrap(), ShouldEqual, cause)
+	})
+}
+
+func TestParseErrors_Error(t *testing.T) {
+	Convey("Contains all parsing errors", t, func() {
+		err := ParseErrors{
+			{Field: "f1", EnvVar: "VAR_1", Err: errors.New("reason 1")},
+			{Field: "f2", EnvVar: "VAR_2", Err: errors.New("reason 2")},
+		}
+
+		So(err.Error(), ShouldContainSubstring, "'f1'")
+		So(err.Error(), ShouldContainSubstring, "'VAR_1'")
+		So(err.Error(), ShouldContainSubstring, "reason 1")
+		So(err.Error(), ShouldContainSubstring, "'f2'")
+		So(err.Error(), ShouldContainSubstring, "'VAR_2'")
+		So(err.Error(), ShouldContainSubstring, "reason 2")
+	})
+}
+
+func TestParseErrors_Unwrap(t *testing.T) {
+	Convey("Allows to inspect parsing errors", t, func() {
+		e1 := ParseError{Field: "f1", EnvVar: "VAR_1", Err: errors.New("r1")}
+		e2 := ParseError{Field: "f2", EnvVar: "VAR_2", Err: errors.New("r2")}
+		var err error = ParseErrors{e1, e2}
+
+		So(err.(ParseErrors).Unwrap(), ShouldResemble, []error{e1, e2})
+		So(errors.Is(err, e2), ShouldBeTrue)
+		var target ParseError
+		So(errors.As(err, &target), ShouldBeTrue)
+		So(target, ShouldResemble, e1)
+	})
+}
@@ -0,0 +1,169 @@
+// Copyright 2017 tyranron
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envigo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// expander expands references to env vars in env var values.
+//
+// Both `$VAR` and `${VAR}` references are supported, along with
+// `${VAR:-default}` (default is used if VAR is unset or empty) and
+// `${VAR-default}` (default is used if VAR is unset only) forms.
+// `$$` is expanded to a single `$`. Values of referenced env vars are
+// expanded recursively.
+type expander struct {
+	// lookup retrieves the value of referenced env var.
+	lookup func(name string) (string, bool)
+	// stack contains names of env vars being expanded, so cycles
+	// can be detected.
+	stack []string
+}
+
+// expand expands all references to env vars in given value of env var
+// with given name.
+func (e *expander) expand(name, val string) (string, error) {
+	for _, n := range e.stack {
+		if n == name {
+			return "", fmt.Errorf("cycle in expansion of env vars: %s -> %s",
+				strings.Join(e.stack, " -> "), name)
+		}
+	}
+	e.stack = append(e.stack, name)
+	defer func() { e.stack = e.stack[:len(e.stack)-1] }()
+	return e.expandValue(val)
+}
+
+// expandValue expands all references to env vars in given value.
+func (e *expander) expandValue(val string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(val); i++ {
+		if val[i] != '$' || i+1 == len(val) {
+			b.WriteByte(val[i])
+			continue
+		}
+		switch c := val[i+1]; {
+		case c == '$':
+			b.WriteByte('$')
+			i++
+		case c == '{':
+			end := matchingBrace(val, i+1)
+			if end < 0 {
+				return "", errUnterminatedRef
+			}
+			ref, err := e.expandRef(val[i+2 : end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(ref)
+			i = end
+		case isNameChar(c) && (c < '0' || c > '9'):
+			end := i + 1
+			for end < len(val) && isNameChar(val[end]) {
+				end++
+			}
+			ref, err := e.expandVar(val[i+1 : end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(ref)
+			i = end - 1
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), nil
+}
+
+// expandRef expands the content of `${...}` reference.
+func (e *expander) expandRef(ref string) (string, error) {
+	end := 0
+	for end < len(ref) && isNameChar(ref[end]) {
+		end++
+	}
+	name, rest := ref[:end], ref[end:]
+	if name == "" {
+		return "", errInvalidRef
+	}
+	var (
+		def      string
+		hasDef   bool
+		useEmpty bool
+	)
+	switch {
+	case rest == "":
+	case strings.HasPrefix(rest, ":-"):
+		def, hasDef = rest[2:], true
+	case strings.HasPrefix(rest, "-"):
+		def, hasDef, useEmpty = rest[1:], true, true
+	default:
+		return "", errInvalidRef
+	}
+	if hasDef {
+		val, exists := e.lookup(name)
+		if !exists || (val == "" && !useEmpty) {
+			return e.expandValue(def)
+		}
+	}
+	return e.expandVar(name)
+}
+
+// Errors of malformed references. They do not mention the value being
+// expanded, as it may be secret.
+var (
+	// errUnterminatedRef is an error indicating that `${` reference
+	// has no closing brace.
+	errUnterminatedRef = errors.New("unterminated '${' reference")
+	// errInvalidRef is an error indicating that `${...}` reference has
+	// no env var name or unsupported form.
+	errInvalidRef = errors.New("invalid '${...}' reference")
+)
+
+// expandVar expands the value of referenced env var with given name.
+// Unset env vars are expanded to empty string.
+func (e *expander) expandVar(name string) (string, error) {
+	val, exists := e.lookup(name)
+	if !exists {
+		return "", nil
+	}
+	return e.expand(name, val)
+}
+
+// matchingBrace returns index of closing brace, which matches the opening
+// one at given index, or -1 if there is no such.
+func matchingBrace(val string, open int) int {
+	depth := 0
+	for i := open; i < len(val); i++ {
+		switch val[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// isNameChar checks whether given character may be a part of env var name.
+func isNameChar(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') ||
+		('0' <= c && c <= '9')
+}
@@ -0,0 +1,83 @@
+// Copyright 2017 tyranron
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envigo
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestExpander_Expand(t *testing.T) {
+	e := expander{lookup: mapEnv(map[string]string{
+		"HOST":   "localhost",
+		"PORT":   "80",
+		"EMPTY":  "",
+		"URL":    "http://$HOST:${PORT}",
+		"SELF":   "a$SELF",
+		"CYCLE1": "${CYCLE2}",
+		"CYCLE2": "${CYCLE3:-x}",
+		"CYCLE3": "$CYCLE1",
+	})}
+
+	Convey("Expands references to env vars", t, func() {
+		for val, expected := range map[string]string{
+			"$HOST/data":                  "localhost/data",
+			"${HOST}data":                 "localhostdata",
+			"$URL/api":                    "http://localhost:80/api",
+			"$UNSET|${UNSET}":             "|",
+			"${UNSET:-8080}":              "8080",
+			"${EMPTY:-8080}":              "8080",
+			"${EMPTY-8080}":               "",
+			"${UNSET-8080}":               "8080",
+			"${PORT:-8080}":               "80",
+			"${UNSET:-${HOST}:${PORT}}":   "localhost:80",
+			"$$HOST costs $5 or $":        "$HOST costs $5 or $",
+			"${UNSET:-$$}":                "$",
+			"${UNSET:-${UNSET2:-nested}}": "nested",
+		} {
+			res, err := e.expand("VAL", val)
+
+			So(err, ShouldBeNil)
+			So(res, ShouldEqual, expected)
+		}
+	})
+
+	Convey("Detects cycles", t, func() {
+		_, err := e.expand("VAL", "$SELF")
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "VAL -> SELF -> SELF")
+
+		_, err = e.expand("VAL", "$CYCLE1")
+
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring,
+			"VAL -> CYCLE1 -> CYCLE2 -> CYCLE3 -> CYCLE1")
+	})
+
+	Convey("Returns error on malformed reference", t, func() {
+		for val, expected := range map[string]error{
+			"s3cr3t${HOST":    errUnterminatedRef,
+			"s3cr3t${}":       errInvalidRef,
+			"s3cr3t${HOST:1}": errInvalidRef,
+		} {
+			_, err := e.expand("VAL", val)
+
+			So(err, ShouldEqual, expected)
+			So(err.Error(), ShouldNotContainSubstring, "s3cr3t")
+		}
+	})
+}
@@ -0,0 +1,104 @@
+// Copyright 2017 tyranron
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envigo
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NameFunc derives env var name from given path of struct field, which
+// consists of names of the field and its parent struct fields (up to the
+// nearest struct field tagged with `envPrefix`).
+type NameFunc func(path []string) string
+
+// ScreamingSnakeNames derives env var name by converting each struct field
+// name of given path into SCREAMING_SNAKE_CASE and joining them with
+// underscores, so `Server.ReadTimeout` becomes `SERVER_READ_TIMEOUT`.
+func ScreamingSnakeNames(path []string) string {
+	names := make([]string, 0, len(path))
+	for _, name := range path {
+		names = append(names, screamingSnake(name))
+	}
+	return strings.Join(names, "_")
+}
+
+// AsIsNames derives env var name by joining struct field names of given path
+// with underscores as they are, so `Server.ReadTimeout` becomes
+// `Server_ReadTimeout`.
+func AsIsNames(path []string) string {
+	return strings.Join(path, "_")
+}
+
+// screamingSnake converts given CamelCase name into SCREAMING_SNAKE_CASE.
+// Abbreviations are kept together, so `TLSCertFile` becomes `TLS_CERT_FILE`.
+func screamingSnake(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && runes[i-1] != '_' {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// suggest returns the name of given known env vars, which is the most similar
+// to given one, or empty string if there is no similar enough.
+func suggest(name string, known []string) string {
+	best, bestDist := "", len(name)/3+2
+	for _, k := range known {
+		if d := editDistance(name, k); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+// editDistance returns Levenshtein distance between given strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minOf(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// minOf returns the smallest of given numbers.
+func minOf(first int, rest ...int) int {
+	for _, n := range rest {
+		if n < first {
+			first = n
+		}
+	}
+	return first
+}
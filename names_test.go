@@ -0,0 +1,75 @@
+// Copyright 2017 tyranron
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envigo
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestScreamingSnakeNames(t *testing.T) {
+	Convey("Converts field names into SCREAMING_SNAKE_CASE", t, func() {
+		for name, expected := range map[string]string{
+			"Port":        "PORT",
+			"ReadTimeout": "READ_TIMEOUT",
+			"TLSCertFile": "TLS_CERT_FILE",
+			"UserID":      "USER_ID",
+			"V2Config":    "V2_CONFIG",
+			"Max_Conns":   "MAX_CONNS",
+			"lower":       "LOWER",
+		} {
+			So(ScreamingSnakeNames([]string{name}), ShouldEqual, expected)
+		}
+	})
+
+	Convey("Joins path with underscores", t, func() {
+		path := []string{"Server", "HTTPTimeouts", "Read"}
+
+		So(ScreamingSnakeNames(path), ShouldEqual, "SERVER_HTTP_TIMEOUTS_READ")
+	})
+}
+
+func TestAsIsNames(t *testing.T) {
+	Convey("Joins path with underscores as is", t, func() {
+		path := []string{"Server", "ReadTimeout"}
+
+		So(AsIsNames(path), ShouldEqual, "Server_ReadTimeout")
+	})
+}
+
+func TestSuggest(t *testing.T) {
+	Convey("Returns the most similar known name", t, func() {
+		known := []string{"MYAPP_WORKERS_COUNT", "MYAPP_WORKERS", "MYAPP_HOST"}
+
+		So(suggest("MYAPP_WORKER_COUNT", known), ShouldEqual,
+			"MYAPP_WORKERS_COUNT")
+		So(suggest("MYAPP_HOTS", known), ShouldEqual, "MYAPP_HOST")
+	})
+
+	Convey("Returns nothing if no name is similar enough", t, func() {
+		So(suggest("MYAPP_DEBUG", []string{"MYAPP_HOST"}), ShouldBeEmpty)
+		So(suggest("MYAPP_DEBUG", nil), ShouldBeEmpty)
+	})
+}
+
+func TestEditDistance(t *testing.T) {
+	Convey("Computes Levenshtein distance", t, func() {
+		So(editDistance("", ""), ShouldEqual, 0)
+		So(editDistance("abc", ""), ShouldEqual, 3)
+		So(editDistance("kitten", "sitting"), ShouldEqual, 3)
+		So(editDistance("HOST", "HOTS"), ShouldEqual, 2)
+	})
+}
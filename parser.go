@@ -18,25 +18,123 @@ package envigo
 
 import (
 	"encoding"
+	"errors"
+	"fmt"
 	"os"
 	refl "reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 )
 
-// TODO: think about different behavior/mode
-
 // Parse performs parsing with default parser.
 func Parse(obj interface{}) error {
 	return Parser{}.Parse(obj)
 }
 
+// Validator is implemented by structs, which validate themselves after all
+// their fields are parsed (like checking cross-field rules).
+type Validator interface {
+	// Validate returns error if struct is invalid.
+	Validate() error
+}
+
 // Parser is an implementation of environment variables parser.
-type Parser struct{}
+// Zero value is a valid parser with default settings.
+type Parser struct {
+	// Prefix is prepended to names of all env vars.
+	Prefix string
+
+	// TagName is a name of struct field tag, which specifies env var name.
+	// If empty, then `env` is used.
+	TagName string
+
+	// LookupEnv retrieves the value of env var with given name, reporting
+	// whether it is set or not.
+	// If nil, then os.LookupEnv is used.
+	LookupEnv func(name string) (string, bool)
+
+	// Environ lists env vars in the form "name=value" (only names are used),
+	// so maps of nested structs can discover their keys. It should list
+	// env vars of the same source as LookupEnv does.
+	// If nil, then os.Environ is used.
+	Environ func() []string
+
+	// CollectErrors makes parser to continue parsing after a struct field
+	// fails to parse, and to return all such failures at once as ParseErrors
+	// (joined with validation failures, MissingVarError and UnknownVarError,
+	// if any). Other errors still abort parsing immediately.
+	CollectErrors bool
+
+	// RedactValues makes parser to omit raw env var values from ParseError-s,
+	// so they cannot leak into logs. Causes of such ParseError-s are replaced
+	// with their sentinel errors only (like strconv.ErrSyntax). It is always
+	// enabled for struct fields with `secret` option (like
+	// `env:"DB_PASSWORD,secret"`).
+	RedactValues bool
+
+	// AllocPointers makes parser to allocate nil pointers of struct fields
+	// whenever their env vars are set (or have default values). Nil pointers
+	// to nested structs are allocated only if any env var of nested struct
+	// is set, and only if they do not point to a struct of the same type as
+	// one of their parents (like `Next *node` field of `node` struct).
+	// If not set, then nil pointers are left as is, and nothing behind them
+	// is parsed, so required env vars of nested structs behind them are not
+	// checked either.
+	AllocPointers bool
+
+	// AutoNames makes parser to derive env var names of exported struct
+	// fields, which are not tagged with `env` tag (or tagged with an empty
+	// name, like `env:",required"`), from their struct field paths.
+	// Untagged nested structs are still parsed recursively, unless they
+	// implement encoding.TextUnmarshaler.
+	// If nil, then untagged fields are not parsed (except nested structs).
+	AutoNames NameFunc
+
+	// ReadFiles makes parser to read values of all env vars, which are not
+	// set, from files, whose paths are specified by env vars with `_FILE`
+	// suffix (like `DB_PASSWORD_FILE`). It can be enabled for a single
+	// struct field with `file` option (like `env:"DB_PASSWORD,file"`).
+	// ParseError-s of such values refer to env vars with `_FILE` suffix.
+	ReadFiles bool
+
+	// TrimFileNewline makes parser to trim a single trailing newline from
+	// values read from files (see ReadFiles).
+	TrimFileNewline bool
+
+	// ExpandValues makes parser to expand references to other env vars
+	// (like `$HOME` or `${PORT:-8080}`) in all env var values (and default
+	// values) before parsing them. It can be enabled for a single struct
+	// field with `expand` option (like `env:"DATA_DIR,expand"`).
+	// Referenced env vars are retrieved with LookupEnv without Prefix.
+	ExpandValues bool
+
+	// Strict makes parser to report env vars, which have Prefix, but are not
+	// used by any struct field, as UnknownVarError. It has no effect if
+	// Prefix is empty.
+	Strict bool
+
+	// UnsetVars makes parser to unset all env vars, which values have been
+	// parsed successfully, once parsing is done, so they do not leak into
+	// child processes (like secrets). Env vars failed to parse, or not parsed
+	// at all (like the ones of nil pointers), are kept.
+	// It can be enabled for a single struct field with `unset` option (like
+	// `env:"DB_PASSWORD,unset"`).
+	UnsetVars bool
+
+	// UnsetEnv unsets env var with given name (see UnsetVars). It should
+	// unset env vars of the same source as LookupEnv does.
+	// If nil, then os.Unsetenv is used.
+	UnsetEnv func(name string) error
+
+	// decoders contains decoders registered for custom types
+	// (see RegisterDecoder).
+	decoders map[refl.Type]DecoderFunc
+}
 
 // Parse inspects given struct and parses environment variables that were
-// mentioned in struct field tag `env`.
+// mentioned in struct field tag `env` (or the one specified by TagName).
 func (p Parser) Parse(obj interface{}) error {
 	ptr := refl.ValueOf(obj)
 	if ptr.Kind() != refl.Ptr {
@@ -46,12 +144,245 @@ func (p Parser) Parse(obj interface{}) error {
 	if val.Kind() != refl.Struct {
 		return ErrNotStructPtr
 	}
-	return p.parseStruct(val)
+	s := &session{Parser: p}
+	if err := s.parseStruct(val, "", p.Prefix, nil); err != nil {
+		return err
+	}
+	if err := s.validateStruct(val, "", 0); err != nil {
+		return err
+	}
+	if err := s.unsetVars(); err != nil {
+		return err
+	}
+	var errs []error
+	if len(s.errs) > 0 {
+		errs = append(errs, s.errs)
+	}
+	errs = append(errs, s.invalid...)
+	if len(s.missing) > 0 {
+		errs = append(errs, MissingVarError{s.missing})
+	}
+	if unknown := s.unknownVars(); len(unknown) > 0 {
+		errs = append(errs, UnknownVarError{unknown})
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// session holds the state of a single parsing performed by Parser.
+type session struct {
+	Parser
+
+	// missing accumulates required env vars that are not set.
+	missing []MissingVar
+
+	// errs accumulates failures of struct fields parsing when
+	// CollectErrors is set.
+	errs ParseErrors
+
+	// invalid accumulates validation failures (ValidationError-s and
+	// StructValidationError-s) when CollectErrors is set.
+	invalid []error
+
+	// found counts env vars that have been found set.
+	found int
+
+	// environ caches names of env vars listed by Environ.
+	environ []string
+
+	// known contains names of env vars that have been looked up
+	// when Strict is set.
+	known map[string]bool
+
+	// used contains names of env vars, which values have been parsed
+	// successfully and are required to be unset.
+	used []string
+
+	// dryRun makes session to only look up env vars of struct fields
+	// without parsing their values and validating structs.
+	dryRun bool
+
+	// parsing contains types of structs being parsed at the moment, so nil
+	// pointers to recursive structs are not allocated infinitely.
+	parsing map[refl.Type]bool
+}
+
+// parseError creates ParseError for given struct field and env var,
+// redacting env var value if required (or if the field is secret).
+func (s *session) parseError(
+	fieldPath, envName, envValue string, secret bool, cause error,
+) ParseError {
+	if s.RedactValues || secret {
+		if elemErr, ok := cause.(ElementError); ok {
+			elemErr.Err = redact(elemErr.Err)
+			elemErr.Value = ""
+			cause = elemErr
+		} else {
+			cause = redact(cause)
+		}
+		return ParseError{Field: fieldPath, EnvVar: envName, Err: cause}
+	}
+	return ParseError{
+		Field:  fieldPath,
+		EnvVar: envName,
+		Value:  envValue,
+		Err:    cause,
+	}
+}
+
+// fail handles given failure of struct field parsing (ParseError,
+// ValidationError or StructValidationError). It returns given error back,
+// unless CollectErrors is set, in which case the error is remembered and
+// parsing may be continued.
+func (s *session) fail(err error) error {
+	if !s.CollectErrors {
+		return err
+	}
+	if parseErr, ok := err.(ParseError); ok {
+		s.errs = append(s.errs, parseErr)
+	} else {
+		s.invalid = append(s.invalid, err)
+	}
+	return nil
+}
+
+// unknownVars returns env vars listed by Environ, which have Prefix, but
+// have not been looked up, if Strict is set.
+func (s *session) unknownVars() []UnknownVar {
+	if !s.Strict || s.Prefix == "" {
+		return nil
+	}
+	known := make([]string, 0, len(s.known))
+	for name := range s.known {
+		known = append(known, name)
+	}
+	sort.Strings(known)
+	var unknown []UnknownVar
+	for _, name := range s.envNames() {
+		if strings.HasPrefix(name, s.Prefix) && !s.known[name] {
+			unknown = append(unknown, UnknownVar{
+				EnvVar:     name,
+				Suggestion: suggest(name, known),
+			})
+		}
+	}
+	sort.Slice(unknown, func(i, j int) bool {
+		return unknown[i].EnvVar < unknown[j].EnvVar
+	})
+	return unknown
+}
+
+// unsetVars unsets env vars, which have been parsed and are required
+// to be unset.
+func (s *session) unsetVars() error {
+	unset := s.UnsetEnv
+	if unset == nil {
+		unset = os.Unsetenv
+	}
+	for _, name := range s.used {
+		if err := unset(name); err != nil {
+			return fmt.Errorf("envigo: cannot unset '%s' env var: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// failures returns the number of struct fields, which have not been
+// populated so far due to parsing failures or missing env vars.
+func (s *session) failures() int {
+	return len(s.errs) + len(s.missing)
+}
+
+// validateStruct calls Validate() method of given parsed struct, which is
+// located by given path, if the struct implements Validator. Validation is
+// omitted on dry run, or if more than given number of failures has been
+// accumulated, as the struct is not populated completely then.
+func (s *session) validateStruct(
+	structVal refl.Value, path string, failures int,
+) error {
+	if s.dryRun || s.failures() > failures {
+		return nil
+	}
+	v, ok := structVal.Addr().Interface().(Validator)
+	if !ok {
+		return nil
+	}
+	if err := v.Validate(); err != nil {
+		return s.fail(StructValidationError{Struct: path, Err: err})
+	}
+	return nil
 }
 
-// parseStruct performs parsing for given struct.
-func (p Parser) parseStruct(structVal refl.Value) error { // nolint: gocyclo
+// tagName returns name of struct field tag, which specifies env var name.
+func (s *session) tagName() string {
+	if s.TagName == "" {
+		return "env"
+	}
+	return s.TagName
+}
+
+// lookupEnv retrieves the value of env var with given name, reporting
+// whether it is set or not.
+func (s *session) lookupEnv(name string) (string, bool) {
+	if s.Strict {
+		if s.known == nil {
+			s.known = map[string]bool{}
+		}
+		s.known[name] = true
+	}
+	if s.LookupEnv == nil {
+		return os.LookupEnv(name)
+	}
+	return s.LookupEnv(name)
+}
+
+// lookupValue retrieves the value of env var with given name, reporting
+// whether it is set or not. If fromFile is true and env var is not set,
+// then the value is read from the file, whose path is specified by env var
+// with `_FILE` suffix (like `DB_PASSWORD_FILE`), and the name of that env var
+// is returned instead. If the file cannot be read, then its path is returned
+// as the value along with the error.
+func (s *session) lookupValue(
+	name string, fromFile bool,
+) (string, string, bool, error) {
+	val, exists := s.lookupEnv(name)
+	if exists || !fromFile {
+		return name, val, exists, nil
+	}
+	fileName := name + "_FILE"
+	filePath, exists := s.lookupEnv(fileName)
+	if !exists {
+		return name, "", false, nil
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fileName, filePath, true, err
+	}
+	val = string(data)
+	if s.TrimFileNewline && strings.HasSuffix(val, "\n") {
+		val = strings.TrimSuffix(strings.TrimSuffix(val, "\n"), "\r")
+	}
+	return fileName, val, true, nil
+}
+
+// parseStruct performs parsing for given struct, which is located by given
+// path (dot-separated names of parent struct fields). Given prefix is
+// prepended to names of all env vars of the struct, while given names
+// (of parent struct fields since the last prefixed one) are used to derive
+// names of env vars when AutoNames is set.
+func (s *session) parseStruct(
+	structVal refl.Value, path, prefix string, names []string,
+) error {
 	structType := structVal.Type()
+	if !s.parsing[structType] {
+		if s.parsing == nil {
+			s.parsing = map[refl.Type]bool{}
+		}
+		s.parsing[structType] = true
+		defer delete(s.parsing, structType)
+	}
 L:
 	for i := 0; i < structVal.NumField(); i++ {
 		fieldVal := structVal.Field(i)
@@ -61,328 +392,607 @@ L:
 			continue
 		}
 
-		envName, hasTag := structType.Field(i).Tag.Lookup("env")
-		if hasTag {
+		envTag, hasTag := structType.Field(i).Tag.Lookup(s.tagName())
+		// Omit explicitly skipped field
+		if envTag == "-" {
+			continue
+		}
+
+		fieldPath := joinPath(path, structType.Field(i).Name)
+		fieldNames := append(names[:len(names):len(names)],
+			structType.Field(i).Name)
+		envName, envOpts := parseEnvTag(envTag)
+		nested := s.isNestedStruct(structType.Field(i).Type)
+		if nested && envName == "" &&
+			(envOpts.Has("inline") || envOpts.Has("squash")) {
+			// Parse inlined struct recursively without adding its name
+			hasTag, fieldNames = false, names
+		}
+		if s.AutoNames != nil && envName == "" && (hasTag || !nested) {
+			envName, hasTag = s.AutoNames(fieldNames), true
+		}
+		if hasTag && s.isStructCollection(structType.Field(i).Type) {
 			if envName == "" {
-				return EmptyVarNameError{structType.Field(i).Name}
+				return EmptyVarNameError{fieldPath}
 			}
-			if _, exists := os.LookupEnv(envName); !exists {
+			// Collections of structs being parsed already are omitted, as
+			// their elements would be parsed recursively without end.
+			if s.parsing[derefType(fieldVal.Type().Elem())] {
 				continue
 			}
+			var err error
+			if fieldVal.Kind() == refl.Map {
+				err = s.parseStructMap(
+					fieldVal, fieldPath, prefix+envName+"_")
+			} else {
+				err = s.parseStructs(fieldVal, fieldPath, prefix+envName+"_")
+			}
+			if err != nil {
+				return err
+			}
+			continue
 		}
-
-		// Unmarshal with custom unmarshaller
+		var (
+			envValue string
+			isSet    bool
+		)
 		if hasTag {
-			if ok, err := parseAsTextUnmarshaler(fieldVal, envName); ok {
+			if envName == "" {
+				return EmptyVarNameError{fieldPath}
+			}
+			var (
+				val    string
+				exists bool
+				err    error
+			)
+			envName, val, exists, err = s.lookupValue(
+				prefix+envName, s.ReadFiles || envOpts.Has("file"))
+			if err != nil {
+				err = s.fail(s.parseError(
+					fieldPath, envName, val, envOpts.Has("secret"), err))
 				if err != nil {
-					return ParseError{
-						structType.Field(i).Name, envName, err.Error(),
+					return err
+				}
+				continue
+			}
+			if exists {
+				s.found++
+				isSet = true
+			} else {
+				// Fallback to default value if any
+				tag := structType.Field(i).Tag
+				if val, exists = tag.Lookup("default"); !exists {
+					if envOpts.Has("required") {
+						s.missing = append(s.missing, MissingVar{
+							Field: fieldPath, EnvVar: envName,
+						})
 					}
+					continue
 				}
+			}
+			if s.dryRun {
 				continue
 			}
+			envValue = val
+			if s.ExpandValues || envOpts.Has("expand") {
+				e := expander{lookup: s.lookupEnv}
+				if envValue, err = e.expand(envName, envValue); err != nil {
+					err = s.fail(s.parseError(
+						fieldPath, envName, val, envOpts.Has("secret"), err))
+					if err != nil {
+						return err
+					}
+					continue
+				}
+			}
 		}
 
-		// Dereference pointer
-		for fieldVal.Kind() == refl.Ptr {
+		// Dereference pointer, allocating nil ones if required.
+		// The outermost nil pointer is not set until its value is parsed.
+		// Pointers having registered decoder are parsed as is.
+		// Nil pointers to structs being parsed already are never allocated,
+		// as they would be allocated recursively without end.
+		var nilPtr, newPtr refl.Value
+		for fieldVal.Kind() == refl.Ptr &&
+			!s.hasDecoder(fieldVal.Type()) {
 			if fieldVal.IsNil() {
-				continue L
-			}
-			fieldVal = fieldVal.Elem()
-			if hasTag {
-				if ok, err := parseAsTextUnmarshaler(fieldVal, envName); ok {
-					if err != nil {
-						return ParseError{
-							structType.Field(i).Name, envName, err.Error(),
-						}
-					}
+				recursive := s.parsing[derefType(fieldVal.Type())]
+				if !s.AllocPointers || recursive {
 					continue L
 				}
+				ptr := refl.New(fieldVal.Type().Elem())
+				if nilPtr.IsValid() {
+					fieldVal.Set(ptr)
+				} else {
+					nilPtr, newPtr = fieldVal, ptr
+				}
+				fieldVal = ptr
 			}
+			fieldVal = fieldVal.Elem()
 		}
-		fieldKind := fieldVal.Kind()
 
 		// If no `env` tag: omit and parse recursively if struct
 		if !hasTag {
-			if fieldKind == refl.Struct {
-				if err := p.parseStruct(fieldVal); err != nil {
-					return ParseError{
-						structType.Field(i).Name, envName, err.Error(),
+			if fieldVal.Kind() == refl.Struct {
+				envPrefix, hasPrefix := structType.Field(i).Tag.Lookup(
+					"envPrefix")
+				if hasPrefix {
+					fieldNames = nil
+				}
+				found, failures := s.found, s.failures()
+				err := s.parseStruct(
+					fieldVal, fieldPath, prefix+envPrefix, fieldNames)
+				if err != nil {
+					return err
+				}
+				if nilPtr.IsValid() {
+					if s.found == found {
+						continue
 					}
+					nilPtr.Set(newPtr)
+				}
+				err = s.validateStruct(fieldVal, fieldPath, failures)
+				if err != nil {
+					return err
 				}
 			}
 			continue
 		}
 
-		envValue := os.Getenv(envName)
-
-		fieldType := fieldVal.Type()
-		switch {
-		// Unmarshal as time.Duration
-		case fieldType.PkgPath() == "time" && fieldType.Name() == "Duration":
-			val, err := time.ParseDuration(envValue)
-			if err != nil {
-				return ParseError{
-					structType.Field(i).Name, envName, err.Error(),
-				}
-			}
-			fieldVal.SetInt(int64(val))
-			continue
+		format := valueFormat{
+			separators: separators{
+				list:   structType.Field(i).Tag.Get("sep"),
+				keyVal: structType.Field(i).Tag.Get("kvsep"),
+			},
+			byteSize: envOpts.Has("bytesize"),
 		}
-		// Unmarshal as primitive type
-		switch fieldKind {
-		case refl.Bool:
-			val, err := strconv.ParseBool(envValue)
-			if err != nil {
-				return ParseError{
-					structType.Field(i).Name, envName, err.Error(),
-				}
+		if err := s.parseValue(fieldVal, envValue, format); err != nil {
+			if err == errUnparsable {
+				return UnparsableTypeError{fieldPath}
 			}
-			fieldVal.SetBool(val)
-		case refl.String:
-			fieldVal.SetString(envValue)
-		case refl.Int, refl.Int8, refl.Int16, refl.Int32, refl.Int64:
-			val, err := strconv.ParseInt(envValue, 0, fieldVal.Type().Bits())
+			err = s.fail(s.parseError(
+				fieldPath, envName, envValue, envOpts.Has("secret"), err))
 			if err != nil {
-				return ParseError{
-					structType.Field(i).Name, envName, err.Error(),
-				}
-			}
-			fieldVal.SetInt(val)
-		case refl.Uint, refl.Uint8, refl.Uint16, refl.Uint32, refl.Uint64:
-			val, err := strconv.ParseUint(envValue, 0, fieldVal.Type().Bits())
-			if err != nil {
-				return ParseError{
-					structType.Field(i).Name, envName, err.Error(),
-				}
+				return err
 			}
-			fieldVal.SetUint(val)
-		case refl.Float32, refl.Float64:
-			val, err := strconv.ParseFloat(envValue, fieldVal.Type().Bits())
-			if err != nil {
-				return ParseError{
-					structType.Field(i).Name, envName, err.Error(),
-				}
+			continue
+		}
+		err := s.validate(
+			fieldVal, structType.Field(i), envOpts, fieldPath, envName)
+		if err != nil {
+			if _, ok := err.(ValidationError); !ok {
+				return err
 			}
-			fieldVal.SetFloat(val)
-		case refl.Array:
-			vals := strings.Split(envValue, ",")
-			if len(vals) > fieldType.Len() {
-				vals = vals[:fieldType.Len()]
+			if err = s.fail(err); err != nil {
+				return err
 			}
-			elemType := fieldType.Elem()
-			elemKind := elemType.Kind()
-			elemVal := fieldVal.Index(0)
-			if _, ok := elemVal.Interface().(encoding.TextUnmarshaler); ok {
-				for i, val := range vals {
-					err := fieldVal.Index(i).
-						Interface().(encoding.TextUnmarshaler).
-						UnmarshalText([]byte(val))
-					if err != nil {
-						return ParseError{
-							structType.Field(i).Name, envName, err.Error(),
-						}
-					}
-				}
-				goto ARRAY_SET_FIELD
+			continue
+		}
+		if nilPtr.IsValid() {
+			nilPtr.Set(newPtr)
+		}
+		if isSet && (s.UnsetVars || envOpts.Has("unset")) {
+			s.used = append(s.used, envName)
+		}
+	}
+	return nil
+}
+
+// parseStructs performs parsing for given array or slice of structs (or
+// pointers to them), which is located by given path. Elements are parsed
+// from indexed env vars (like `UPSTREAM_0_HOST`, `UPSTREAM_1_HOST`), where
+// given prefix is followed by consecutive indices starting from 0, until an
+// element having no env vars set is met. Value is not mutated if there are no
+// elements at all.
+func (s *session) parseStructs(val refl.Value, path, prefix string) error {
+	var slice refl.Value
+	if val.Kind() == refl.Slice {
+		slice = refl.MakeSlice(val.Type(), 0, 0)
+	}
+	for i := 0; slice.IsValid() || i < val.Len(); i++ {
+		elem := refl.New(val.Type().Elem()).Elem()
+		if !slice.IsValid() {
+			elem.Set(val.Index(i))
+		}
+		structVal := elem
+		for structVal.Kind() == refl.Ptr {
+			if structVal.IsNil() {
+				structVal.Set(refl.New(structVal.Type().Elem()))
 			}
-			if elemVal.CanAddr() {
-				if _, ok := elemVal.Addr().
-					Interface().(encoding.TextUnmarshaler); ok {
-					for i, val := range vals {
-						err := fieldVal.Index(i).Addr().
-							Interface().(encoding.TextUnmarshaler).
-							UnmarshalText([]byte(val))
-						if err != nil {
-							return ParseError{
-								structType.Field(i).Name, envName, err.Error(),
-							}
-						}
-					}
-					goto ARRAY_SET_FIELD
-				}
+			structVal = structVal.Elem()
+		}
+
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		found, failures := s.found, s.failures()
+		errs, invalid, missing := len(s.errs), len(s.invalid), len(s.missing)
+		err := s.parseStruct(
+			structVal, elemPath, prefix+strconv.Itoa(i)+"_", nil)
+		if err != nil {
+			return err
+		}
+		if s.found == found {
+			// Forget failures of non-existent element
+			s.errs, s.invalid = s.errs[:errs], s.invalid[:invalid]
+			s.missing = s.missing[:missing]
+			break
+		}
+		if err = s.validateStruct(structVal, elemPath, failures); err != nil {
+			return err
+		}
+
+		if slice.IsValid() {
+			slice = refl.Append(slice, elem)
+		} else {
+			val.Index(i).Set(elem)
+		}
+	}
+	if slice.IsValid() && slice.Len() > 0 {
+		val.Set(slice)
+	}
+	return nil
+}
+
+// parseStructMap performs parsing for given map of structs (or pointers to
+// them), which is located by given path. Entries are parsed from env vars
+// having given prefix followed by map key and names of env vars of value
+// struct (like `CACHE_USERS_TTL`, `CACHE_USERS_SIZE`, `CACHE_TOKENS_TTL`),
+// so keys are discovered by scanning env vars listed by Environ.
+// Value is not mutated if there are no entries at all.
+func (s *session) parseStructMap(val refl.Value, path, prefix string) error {
+	typ := val.Type()
+	suffixes := s.structEnvNames(typ.Elem())
+	keys := map[string]bool{}
+	for _, name := range s.envNames() {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		// Longest matching suffix wins, so keys cannot end with a part
+		// of env var name.
+		rest, key := name[len(prefix):], ""
+		for _, suffix := range suffixes {
+			k := strings.TrimSuffix(rest, "_"+suffix)
+			if k != rest && k != "" && (key == "" || len(k) < len(key)) {
+				key = k
 			}
-			switch {
-			// Unmarshal as time.Duration
-			case elemType.PkgPath() == "time" && elemType.Name() == "Duration":
-				for i, val := range vals {
-					val, err := time.ParseDuration(val)
-					if err != nil {
-						return ParseError{
-							structType.Field(i).Name, envName, err.Error(),
-						}
-					}
-					fieldVal.Index(i).SetInt(int64(val))
-				}
-				goto ARRAY_SET_FIELD
+		}
+		if key != "" {
+			keys[key] = true
+		}
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	m := refl.MakeMap(typ)
+	for _, key := range sortedKeys {
+		elemPath := fmt.Sprintf("%s[%s]", path, key)
+		mapKey := refl.New(typ.Key()).Elem()
+		var err error
+		if !s.dryRun {
+			err = s.parseScalar(mapKey, key, valueFormat{})
+		}
+		if err != nil {
+			if err == errUnparsable {
+				return UnparsableTypeError{path}
 			}
-			switch elemKind {
-			case refl.Bool:
-				for i, val := range vals {
-					val, err := strconv.ParseBool(val)
-					if err != nil {
-						return ParseError{
-							structType.Field(i).Name, envName, err.Error(),
-						}
-					}
-					fieldVal.Index(i).SetBool(val)
-				}
-			case refl.String:
-				for i, val := range vals {
-					fieldVal.Index(i).SetString(val)
-				}
-			case refl.Int, refl.Int8, refl.Int16, refl.Int32, refl.Int64:
-				for i, val := range vals {
-					val, err := strconv.ParseInt(val, 0, elemType.Bits())
-					if err != nil {
-						return ParseError{
-							structType.Field(i).Name, envName, err.Error(),
-						}
-					}
-					fieldVal.Index(i).SetInt(val)
-				}
-			case refl.Uint, refl.Uint8, refl.Uint16, refl.Uint32, refl.Uint64:
-				for i, val := range vals {
-					val, err := strconv.ParseUint(val, 0, elemType.Bits())
-					if err != nil {
-						return ParseError{
-							structType.Field(i).Name, envName, err.Error(),
-						}
-					}
-					fieldVal.Index(i).SetUint(val)
-				}
-			case refl.Float32, refl.Float64:
-				for i, val := range vals {
-					val, err := strconv.ParseFloat(val, elemType.Bits())
-					if err != nil {
-						return ParseError{
-							structType.Field(i).Name, envName, err.Error(),
-						}
-					}
-					fieldVal.Index(i).SetFloat(val)
-				}
-			default:
-				return UnparsableTypeError{structType.Field(i).Name}
+			err = s.fail(s.parseError(elemPath, prefix+key, key, false, err))
+			if err != nil {
+				return err
 			}
-		ARRAY_SET_FIELD:
-			fieldVal.Set(fieldVal)
-		case refl.Slice:
-			vals := strings.Split(envValue, ",")
-			elemType := fieldType.Elem()
-			elemKind := elemType.Kind()
-			fieldSliceVal := refl.MakeSlice(
-				refl.SliceOf(elemType), len(vals), len(vals))
-			elemVal := fieldSliceVal.Index(0)
-			if _, ok := elemVal.Interface().(encoding.TextUnmarshaler); ok {
-				for i, val := range vals {
-					err := fieldSliceVal.Index(i).
-						Interface().(encoding.TextUnmarshaler).
-						UnmarshalText([]byte(val))
-					if err != nil {
-						return ParseError{
-							structType.Field(i).Name, envName, err.Error(),
-						}
-					}
-				}
-				goto SLICE_SET_FIELD
+			continue
+		}
+		elem := refl.New(typ.Elem()).Elem()
+		structVal := elem
+		for structVal.Kind() == refl.Ptr {
+			structVal.Set(refl.New(structVal.Type().Elem()))
+			structVal = structVal.Elem()
+		}
+		failures := s.failures()
+		err = s.parseStruct(structVal, elemPath, prefix+key+"_", nil)
+		if err != nil {
+			return err
+		}
+		if err = s.validateStruct(structVal, elemPath, failures); err != nil {
+			return err
+		}
+		m.SetMapIndex(mapKey, elem)
+	}
+	if m.Len() > 0 {
+		val.Set(m)
+	}
+	return nil
+}
+
+// structEnvNames returns names of env vars (without any prefix), which are
+// looked up when parsing struct of given type (possibly behind pointer).
+// Nil pointers and collections of recursive structs are not walked through
+// (see parsing), so the names are finite.
+func (s *session) structEnvNames(typ refl.Type) []string {
+	typ = derefType(typ)
+	var names []string
+	dry := &session{Parser: s.Parser, dryRun: true}
+	dry.CollectErrors, dry.AllocPointers = true, true
+	dry.LookupEnv = func(name string) (string, bool) {
+		names = append(names, name)
+		return "", false
+	}
+	dry.parseStruct(refl.New(typ).Elem(), "", "", nil) // nolint: errcheck
+	return names
+}
+
+// envNames returns names of all env vars listed by Environ.
+func (s *session) envNames() []string {
+	if s.environ == nil {
+		environ := os.Environ
+		if s.Environ != nil {
+			environ = s.Environ
+		}
+		s.environ = []string{}
+		for _, kv := range environ() {
+			if name := strings.SplitN(kv, "=", 2)[0]; name != "" {
+				s.environ = append(s.environ, name)
 			}
-			if elemVal.CanAddr() {
-				if _, ok := elemVal.Addr().
-					Interface().(encoding.TextUnmarshaler); ok {
-					for i, val := range vals {
-						err := fieldSliceVal.Index(i).Addr().
-							Interface().(encoding.TextUnmarshaler).
-							UnmarshalText([]byte(val))
-						if err != nil {
-							return ParseError{
-								structType.Field(i).Name, envName, err.Error(),
-							}
-						}
-					}
-					goto SLICE_SET_FIELD
-				}
+		}
+	}
+	return s.environ
+}
+
+// isStructCollection checks whether given struct field type is an array,
+// slice or map of nested structs (see isNestedStruct), which elements are
+// parsed from indexed (or keyed) env vars.
+func (s *session) isStructCollection(typ refl.Type) bool {
+	switch typ.Kind() {
+	case refl.Array, refl.Slice, refl.Map:
+		return !s.hasDecoder(typ) &&
+			!refl.PtrTo(typ).Implements(textUnmarshalerType) &&
+			s.isNestedStruct(typ.Elem())
+	}
+	return false
+}
+
+// derefType returns given type with all pointers dereferenced.
+func derefType(typ refl.Type) refl.Type {
+	for typ.Kind() == refl.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+// joinPath appends given struct field name to the path of its parent struct.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// textUnmarshalerType is a reflected type of encoding.TextUnmarshaler.
+var textUnmarshalerType = refl.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// isNestedStruct checks whether given struct field type (possibly behind
+// pointers) is a struct, which should be parsed recursively rather than
+// from a single env var value.
+func (s *session) isNestedStruct(typ refl.Type) bool {
+	for typ.Kind() == refl.Ptr {
+		if s.hasDecoder(typ) {
+			return false
+		}
+		typ = typ.Elem()
+	}
+	return typ.Kind() == refl.Struct && !s.hasDecoder(typ) &&
+		!refl.PtrTo(typ).Implements(textUnmarshalerType)
+}
+
+// parseAsTextUnmarshaler tries to parse given env var value
+// with encoding.TextUnmarshaler implementation.
+func parseAsTextUnmarshaler(
+	fieldVal refl.Value, envValue string,
+) (bool, error) {
+	if field, ok := fieldVal.Interface().(encoding.TextUnmarshaler); ok {
+		return true, field.UnmarshalText([]byte(envValue))
+	}
+	if fieldVal.CanAddr() {
+		return parseAsTextUnmarshaler(fieldVal.Addr(), envValue)
+	}
+	return false, nil
+}
+
+// errUnparsable is an internal error indicating that there is no parser
+// for the type of value.
+var errUnparsable = errors.New("type is not parsable from string")
+
+// valueFormat describes how env var value is parsed.
+type valueFormat struct {
+	separators
+	// byteSize makes numbers to be parsed as byte sizes or quantities
+	// (see parseQuantity).
+	byteSize bool
+}
+
+// separators describes how collections are split from env var value.
+type separators struct {
+	// list separates elements of arrays and slices, and entries of maps.
+	// If empty, then comma is used.
+	// If consists of whitespaces only, then value is split around each
+	// instance of one or more consecutive whitespaces.
+	list string
+	// keyVal separates key from value in map entries.
+	// If empty, then colon is used.
+	keyVal string
+}
+
+// split splits given env var value into collection elements.
+func (s separators) split(envValue string) []string {
+	switch {
+	case s.list == "":
+		return strings.Split(envValue, ",")
+	case strings.TrimSpace(s.list) == "":
+		return strings.Fields(envValue)
+	default:
+		return strings.Split(envValue, s.list)
+	}
+}
+
+// splitKeyVal splits given map entry into key and value.
+func (s separators) splitKeyVal(entry string) ([]string, error) {
+	sep := s.keyVal
+	if sep == "" {
+		sep = ":"
+	}
+	kv := strings.SplitN(entry, sep, 2)
+	if len(kv) != 2 {
+		return nil, fmt.Errorf(
+			"map entry '%s' is not in 'key%svalue' format", entry, sep)
+	}
+	return kv, nil
+}
+
+// parseValue parses given env var value into given settable value.
+// Arrays and slices are parsed from separated elements, while maps are
+// parsed from separated `key:value` pairs, unless there is a decoder
+// registered for their type.
+func (s *session) parseValue(
+	val refl.Value, envValue string, format valueFormat,
+) error {
+	if ok, err := s.parseWithDecoder(val, envValue); ok {
+		return err
+	}
+	typ := val.Type()
+	switch typ.Kind() {
+	case refl.Array:
+		if ok, err := parseAsTextUnmarshaler(val, envValue); ok {
+			return err
+		}
+		vals := format.split(envValue)
+		if len(vals) > typ.Len() {
+			vals = vals[:typ.Len()]
+		}
+		for i, v := range vals {
+			if err := s.parseScalar(val.Index(i), v, format); err != nil {
+				return elementError(i, v, err)
 			}
-			switch {
-			// Unmarshal as time.Duration
-			case elemType.PkgPath() == "time" && elemType.Name() == "Duration":
-				for i, val := range vals {
-					val, err := time.ParseDuration(val)
-					if err != nil {
-						return ParseError{
-							structType.Field(i).Name, envName, err.Error(),
-						}
-					}
-					fieldSliceVal.Index(i).SetInt(int64(val))
-				}
-				goto SLICE_SET_FIELD
+		}
+	case refl.Slice:
+		if ok, err := parseAsTextUnmarshaler(val, envValue); ok {
+			return err
+		}
+		vals := format.split(envValue)
+		slice := refl.MakeSlice(typ, len(vals), len(vals))
+		for i, v := range vals {
+			if err := s.parseScalar(slice.Index(i), v, format); err != nil {
+				return elementError(i, v, err)
 			}
-			switch elemKind {
-			case refl.Bool:
-				for i, val := range vals {
-					val, err := strconv.ParseBool(val)
-					if err != nil {
-						return ParseError{
-							structType.Field(i).Name, envName, err.Error(),
-						}
-					}
-					fieldSliceVal.Index(i).SetBool(val)
-				}
-			case refl.String:
-				for i, val := range vals {
-					fieldSliceVal.Index(i).SetString(val)
-				}
-			case refl.Int, refl.Int8, refl.Int16, refl.Int32, refl.Int64:
-				for i, val := range vals {
-					val, err := strconv.ParseInt(val, 0, elemType.Bits())
-					if err != nil {
-						return ParseError{
-							structType.Field(i).Name, envName, err.Error(),
-						}
-					}
-					fieldSliceVal.Index(i).SetInt(val)
+		}
+		val.Set(slice)
+	case refl.Map:
+		if ok, err := parseAsTextUnmarshaler(val, envValue); ok {
+			return err
+		}
+		m := refl.MakeMap(typ)
+		if strings.TrimSpace(envValue) != "" {
+			for i, entry := range format.split(envValue) {
+				kv, err := format.splitKeyVal(entry)
+				if err != nil {
+					return elementError(i, entry, err)
 				}
-			case refl.Uint, refl.Uint8, refl.Uint16, refl.Uint32, refl.Uint64:
-				for i, val := range vals {
-					val, err := strconv.ParseUint(val, 0, elemType.Bits())
-					if err != nil {
-						return ParseError{
-							structType.Field(i).Name, envName, err.Error(),
-						}
-					}
-					fieldSliceVal.Index(i).SetUint(val)
+				key := refl.New(typ.Key()).Elem()
+				if err := s.parseScalar(key, kv[0], format); err != nil {
+					return elementError(i, entry, err)
 				}
-			case refl.Float32, refl.Float64:
-				for i, val := range vals {
-					val, err := strconv.ParseFloat(val, elemType.Bits())
-					if err != nil {
-						return ParseError{
-							structType.Field(i).Name, envName, err.Error(),
-						}
-					}
-					fieldSliceVal.Index(i).SetFloat(val)
+				elem := refl.New(typ.Elem()).Elem()
+				if err := s.parseScalar(elem, kv[1], format); err != nil {
+					return elementError(i, entry, err)
 				}
-			default:
-				return UnparsableTypeError{structType.Field(i).Name}
+				m.SetMapIndex(key, elem)
 			}
-		SLICE_SET_FIELD:
-			fieldVal.Set(fieldSliceVal)
-		default:
-			return UnparsableTypeError{structType.Field(i).Name}
 		}
+		val.Set(m)
+	default:
+		return s.parseScalar(val, envValue, format)
 	}
 	return nil
 }
 
-// parseAsTextUnmarshaler tries to parse value from environment variable
-// with encoding.TextUnmarshaler implementation.
-func parseAsTextUnmarshaler(
-	fieldVal refl.Value, envName string,
-) (bool, error) {
-	if field, ok := fieldVal.Interface().(encoding.TextUnmarshaler); ok {
-		return true, field.UnmarshalText([]byte(os.Getenv(envName)))
+// elementError wraps given failure of collection element parsing
+// into ElementError.
+func elementError(index int, value string, err error) error {
+	if err == errUnparsable {
+		return err
 	}
-	if fieldVal.CanAddr() {
-		return parseAsTextUnmarshaler(fieldVal.Addr(), envName)
+	return ElementError{Index: index, Value: value, Err: err}
+}
+
+// parseScalar parses given string into given settable value, which cannot be
+// a collection (array, slice or map) unless it implements
+// encoding.TextUnmarshaler or has a registered decoder. Registered decoders
+// take priority over any other parsing. Nil pointers are allocated.
+func (s *session) parseScalar( // nolint: gocyclo
+	val refl.Value, str string, format valueFormat,
+) error {
+	if ok, err := s.parseWithDecoder(val, str); ok {
+		return err
 	}
-	return false, nil
+	typ := val.Type()
+	if typ.Kind() == refl.Ptr {
+		if val.IsNil() {
+			val.Set(refl.New(typ.Elem()))
+		}
+		return s.parseScalar(val.Elem(), str, format)
+	}
+
+	// Unmarshal with custom unmarshaller
+	if ok, err := parseAsTextUnmarshaler(val, str); ok {
+		return err
+	}
+
+	switch {
+	// Unmarshal as time.Duration
+	case typ.PkgPath() == "time" && typ.Name() == "Duration":
+		v, err := time.ParseDuration(str)
+		if err != nil {
+			return err
+		}
+		val.SetInt(int64(v))
+		return nil
+	// Unmarshal as byte size or quantity
+	case format.byteSize:
+		if ok, err := parseQuantityInto(val, str); ok {
+			return err
+		}
+	}
+
+	// Unmarshal as primitive type
+	switch typ.Kind() {
+	case refl.Bool:
+		v, err := strconv.ParseBool(str)
+		if err != nil {
+			return err
+		}
+		val.SetBool(v)
+	case refl.String:
+		val.SetString(str)
+	case refl.Int, refl.Int8, refl.Int16, refl.Int32, refl.Int64:
+		v, err := strconv.ParseInt(str, 0, typ.Bits())
+		if err != nil {
+			return err
+		}
+		val.SetInt(v)
+	case refl.Uint, refl.Uint8, refl.Uint16, refl.Uint32, refl.Uint64:
+		v, err := strconv.ParseUint(str, 0, typ.Bits())
+		if err != nil {
+			return err
+		}
+		val.SetUint(v)
+	case refl.Float32, refl.Float64:
+		v, err := strconv.ParseFloat(str, typ.Bits())
+		if err != nil {
+			return err
+		}
+		val.SetFloat(v)
+	default:
+		return errUnparsable
+	}
+	return nil
 }
@@ -16,8 +16,16 @@ package envigo
 
 import (
 	"errors"
+	"log/slog"
 	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
 	"os"
+	"path/filepath"
+	refl "reflect"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -289,6 +297,102 @@ func TestParser_Parse(t *testing.T) {
 				So(obj.V6.String(), ShouldEqual, ipv6)
 			})
 
+			Convey("url.URL", func() {
+				setEnv("URL", "https://user@example.com:8443/api?v=1")
+				obj := &struct {
+					V1 url.URL  `env:"URL"`
+					V2 *url.URL `env:"URL"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V1.Host, ShouldEqual, "example.com:8443")
+				So(obj.V2, ShouldNotBeNil)
+				So(obj.V2.String(), ShouldEqual,
+					"https://user@example.com:8443/api?v=1")
+			})
+
+			Convey("regexp.Regexp", func() {
+				setEnv("REGEXP", "^[a-z]+$")
+				obj := &struct {
+					V *regexp.Regexp `env:"REGEXP"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V.MatchString("abc"), ShouldBeTrue)
+				So(obj.V.MatchString("ABC"), ShouldBeFalse)
+			})
+
+			Convey("net.IPNet", func() {
+				setEnv("CIDR", "10.1.2.3/8")
+				obj := &struct {
+					V1 net.IPNet  `env:"CIDR"`
+					V2 *net.IPNet `env:"CIDR"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V1.String(), ShouldEqual, "10.0.0.0/8")
+				So(obj.V2.Contains(net.ParseIP("10.200.0.1")), ShouldBeTrue)
+			})
+
+			Convey("netip.Addr, netip.Prefix and netip.AddrPort", func() {
+				setEnv("NETIP_ADDR", "::1")
+				setEnv("NETIP_PREFIX", "192.168.0.0/16")
+				setEnv("NETIP_ADDR_PORT", "127.0.0.1:8080")
+				obj := &struct {
+					V1 netip.Addr     `env:"NETIP_ADDR"`
+					V2 netip.Prefix   `env:"NETIP_PREFIX"`
+					V3 netip.AddrPort `env:"NETIP_ADDR_PORT"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V1, ShouldEqual, netip.IPv6Loopback())
+				So(obj.V2.Bits(), ShouldEqual, 16)
+				So(obj.V3.Port(), ShouldEqual, 8080)
+			})
+
+			Convey("time.Location", func() {
+				setEnv("LOCATION", "UTC")
+				obj := &struct {
+					V *time.Location `env:"LOCATION"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V, ShouldEqual, time.UTC)
+			})
+
+			Convey("os.FileMode", func() {
+				setEnv("FILE_MODE", "0640")
+				setEnv("FILE_MODE_NO_PREFIX", "755")
+				setEnv("FILE_MODE_O_PREFIX", "0o600")
+				obj := &struct {
+					V1 os.FileMode `env:"FILE_MODE"`
+					V2 os.FileMode `env:"FILE_MODE_NO_PREFIX"`
+					V3 os.FileMode `env:"FILE_MODE_O_PREFIX"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V1, ShouldEqual, os.FileMode(0640))
+				So(obj.V2, ShouldEqual, os.FileMode(0755))
+				So(obj.V3, ShouldEqual, os.FileMode(0600))
+			})
+
+			Convey("slog.Level", func() {
+				setEnv("LOG_LEVEL", "warn")
+				obj := &struct {
+					V slog.Level `env:"LOG_LEVEL"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V, ShouldEqual, slog.LevelWarn)
+			})
+
 			Convey("array of", func() {
 				Convey("bool", func() {
 					setEnv("ARRAY_BOOL", "false,true,false")
@@ -715,6 +819,128 @@ func TestParser_Parse(t *testing.T) {
 					So(obj.V[1].String(), ShouldEqual, "2001:db8:a0b:12f0::1")
 				})
 			})
+
+			Convey("map of", func() {
+				Convey("string to string", func() {
+					setEnv("MAP_STRING", "team:core,env:prod,url:http://a")
+					obj := &struct {
+						V map[string]string `env:"MAP_STRING"`
+					}{map[string]string{"old": "value"}}
+					err := p.Parse(obj)
+
+					So(err, ShouldBeNil)
+					So(obj.V, ShouldResemble, map[string]string{
+						"team": "core", "env": "prod", "url": "http://a",
+					})
+				})
+
+				Convey("int to time.Duration", func() {
+					setEnv("MAP_INT_DURATION", "1:1s,-2:1h3m")
+					obj := &struct {
+						V map[int]time.Duration `env:"MAP_INT_DURATION"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldBeNil)
+					So(obj.V, ShouldResemble, map[int]time.Duration{
+						1: time.Second, -2: time.Hour + 3*time.Minute,
+					})
+				})
+
+				Convey("custom parser type", func() {
+					setEnv("MAP_CUSTOM", "1:2,3:4")
+					obj := &struct {
+						V map[customUint8]*customUint8 `env:"MAP_CUSTOM"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldBeNil)
+					So(obj.V, ShouldHaveLength, 1)
+					So(*obj.V[customUint8(7)], ShouldEqual, 7)
+				})
+
+				Convey("empty value", func() {
+					setEnv("MAP_EMPTY", "")
+					obj := &struct {
+						V map[string]bool `env:"MAP_EMPTY"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldBeNil)
+					So(obj.V, ShouldNotBeNil)
+					So(obj.V, ShouldBeEmpty)
+				})
+			})
+		})
+
+		Convey("Parses byte sizes and quantities", func() {
+			p := Parser{LookupEnv: mapEnv(map[string]string{
+				"SIZE_CACHE":    "512MiB",
+				"SIZE_BUFFER":   "64k",
+				"SIZE_CPU":      "500m",
+				"SIZE_LIMITS":   "1Gi,2Gi",
+				"SIZE_TIMEOUT":  "1s",
+				"SIZE_FRACTION": "1.5",
+				"SIZE_HUGE":     "1Ei",
+			})}
+			obj := &struct {
+				V1 int64         `env:"SIZE_CACHE,bytesize"`
+				V2 uint32        `env:"SIZE_BUFFER,bytesize"`
+				V3 float64       `env:"SIZE_CPU,bytesize"`
+				V4 []uint64      `env:"SIZE_LIMITS,bytesize"`
+				V5 time.Duration `env:"SIZE_TIMEOUT,bytesize"`
+				V6 ByteSize      `env:"SIZE_CACHE"`
+				V7 int           `env:"SIZE_DEFAULT,bytesize" default:"1KiB"`
+				V8 int64         `env:"SIZE_CACHE,bytesize" max:"1GiB"`
+			}{}
+			err := p.Parse(obj)
+
+			So(err, ShouldBeNil)
+			So(obj.V1, ShouldEqual, 512<<20)
+			So(obj.V2, ShouldEqual, 64000)
+			So(obj.V3, ShouldEqual, 0.5)
+			So(obj.V4, ShouldResemble, []uint64{1 << 30, 2 << 30})
+			So(obj.V5, ShouldEqual, time.Second)
+			So(obj.V6, ShouldEqual, 512<<20)
+			So(obj.V7, ShouldEqual, 1024)
+			So(obj.V8, ShouldEqual, 512<<20)
+
+			Convey("Returns error on invalid size", func() {
+				for _, c := range []struct {
+					tag string
+					typ refl.Type
+				}{
+					{`env:"SIZE_CPU,bytesize"`, refl.TypeOf(0)},
+					{`env:"SIZE_FRACTION,bytesize"`, refl.TypeOf(uint(0))},
+					{`env:"SIZE_HUGE,bytesize"`, refl.TypeOf(int32(0))},
+					{`env:"SIZE_CACHE"`, refl.TypeOf(0)},
+				} {
+					obj := refl.New(refl.StructOf([]refl.StructField{{
+						Name: "V", Type: c.typ, Tag: refl.StructTag(c.tag),
+					}}))
+					err := p.Parse(obj.Interface())
+
+					So(err, ShouldNotBeNil)
+					So(err, ShouldHaveSameTypeAs, ParseError{})
+				}
+			})
+		})
+
+		Convey("Splits collections by custom separators", func() {
+			setEnv("SEP_SLICE", "a,b;c,d")
+			setEnv("SEP_ARRAY", " 1  2\n\t3 ")
+			setEnv("SEP_MAP", "a=1,2;b=3")
+			obj := &struct {
+				V1 []string          `env:"SEP_SLICE" sep:";"`
+				V2 [3]int            `env:"SEP_ARRAY" sep:" "`
+				V3 map[string]string `env:"SEP_MAP" sep:";" kvsep:"="`
+			}{}
+			err := p.Parse(obj)
+
+			So(err, ShouldBeNil)
+			So(obj.V1, ShouldResemble, []string{"a,b", "c,d"})
+			So(obj.V2, ShouldResemble, [3]int{1, 2, 3})
+			So(obj.V3, ShouldResemble, map[string]string{"a": "1,2", "b": "3"})
 		})
 
 		Convey("On unsupported type", func() {
@@ -762,6 +988,101 @@ func TestParser_Parse(t *testing.T) {
 				So(err, ShouldBeNil)
 				So(obj.V, ShouldEqual, 5)
 			})
+
+			Convey("Parses default value if specified", func() {
+				unsetEnv("DEFAULT_UINT8")
+				unsetEnv("DEFAULT_DURATION")
+				unsetEnv("DEFAULT_SLICE")
+				unsetEnv("DEFAULT_CUSTOM")
+				obj := &struct {
+					V1 uint8           `env:"DEFAULT_UINT8" default:"12"`
+					V2 time.Duration   `env:"DEFAULT_DURATION" default:"1m5s"`
+					V3 []int           `env:"DEFAULT_SLICE" default:"1,2,3"`
+					V4 customUint8     `env:"DEFAULT_CUSTOM" default:"1"`
+					V5 string          `env:"DEFAULT_EMPTY" default:""`
+					V6 net.IP          `env:"DEFAULT_IP" default:"127.0.0.1"`
+					V7 *time.Duration  `env:"DEFAULT_DURATION" default:"2s"`
+					V8 map[int]float32 `default:"not parsed"`
+				}{V5: "some", V7: new(time.Duration)}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V1, ShouldEqual, 12)
+				So(obj.V2, ShouldEqual, time.Minute+5*time.Second)
+				So(obj.V3, ShouldResemble, []int{1, 2, 3})
+				So(obj.V4, ShouldEqual, 7)
+				So(obj.V5, ShouldEqual, "")
+				So(obj.V6.String(), ShouldEqual, "127.0.0.1")
+				So(*obj.V7, ShouldEqual, 2*time.Second)
+				So(obj.V8, ShouldBeNil)
+			})
+
+			Convey("Returns parsing error on invalid default value", func() {
+				unsetEnv("DEFAULT_INT")
+				obj := &struct {
+					V int `env:"DEFAULT_INT" default:"ten"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldNotBeNil)
+				So(err, ShouldHaveSameTypeAs, ParseError{})
+			})
+		})
+
+		Convey("If required env var is not set", func() {
+			unsetEnv("REQUIRED_INT")
+			unsetEnv("REQUIRED_STRING")
+			setEnv("REQUIRED_BOOL", "true")
+			obj := &struct {
+				V1 int  `env:"REQUIRED_INT,required"`
+				V2 bool `env:"REQUIRED_BOOL,required"`
+				N  struct {
+					V string `env:"REQUIRED_STRING,required"`
+				}
+				V3 int `env:"REQUIRED_INT,required" default:"3"`
+				P  *struct {
+					V int `env:"REQUIRED_INT,required"`
+				}
+			}{}
+			err := p.Parse(obj)
+
+			Convey("Returns error with all missing env vars", func() {
+				So(err, ShouldNotBeNil)
+				So(err, ShouldHaveSameTypeAs, MissingVarError{})
+				So(err.(MissingVarError).Vars, ShouldResemble, []MissingVar{
+					{Field: "V1", EnvVar: "REQUIRED_INT"},
+					{Field: "N.V", EnvVar: "REQUIRED_STRING"},
+				})
+			})
+
+			Convey("Parses other values", func() {
+				So(obj.V2, ShouldBeTrue)
+				So(obj.V3, ShouldEqual, 3)
+				So(obj.P, ShouldBeNil)
+			})
+
+			Convey("Checks nil pointers only if AllocPointers is set", func() {
+				p := Parser{AllocPointers: true}
+				err := p.Parse(obj)
+
+				So(err, ShouldNotBeNil)
+				So(err.(MissingVarError).Vars, ShouldContain,
+					MissingVar{Field: "P.V", EnvVar: "REQUIRED_INT"})
+				So(obj.P, ShouldBeNil)
+			})
+		})
+
+		Convey("If env var is set", func() {
+			Convey("Ignores default value", func() {
+				setEnv("DEFAULT_UINT8", "3")
+				obj := &struct {
+					V uint8 `env:"DEFAULT_UINT8" default:"12"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V, ShouldEqual, 3)
+			})
 		})
 
 		Convey("If env var is empty", func() {
@@ -832,6 +1153,272 @@ func TestParser_Parse(t *testing.T) {
 			})
 		})
 
+		Convey("Skips fields tagged with `-`", func() {
+			setEnv("SKIPPED_INT", "3")
+			type Unsupported struct {
+				V uintptr `env:"SKIPPED_INT"`
+			}
+			obj := &struct {
+				V  int          `env:"-"`
+				N  Unsupported  `env:"-"`
+				N2 *Unsupported `env:"-"`
+				N3 struct {
+					V int `env:"SKIPPED_INT"`
+				} `env:"-"`
+			}{}
+			err := p.Parse(obj)
+
+			So(err, ShouldBeNil)
+			So(obj.V, ShouldEqual, 0)
+			So(obj.N2, ShouldBeNil)
+			So(obj.N3.V, ShouldEqual, 0)
+		})
+
+		Convey("Prefixes env vars of nested structs", func() {
+			setEnv("PRIMARY_DB_HOST", "db1")
+			setEnv("PRIMARY_DB_PORT", "5432")
+			setEnv("REPLICA_DB_HOST", "db2")
+			setEnv("REPLICA_DB_PORT", "5433")
+			setEnv("EMB_EMBEDDED_BOOL", "true")
+			setEnv("EMB_EMBEDDED_INT", "4")
+			type DB struct {
+				Host string `env:"HOST"`
+				Port int    `env:"PORT"`
+			}
+			obj := &struct {
+				*EmbeddedStruct `envPrefix:"EMB_"`
+				Primary         struct {
+					DB `envPrefix:"DB_"`
+				} `envPrefix:"PRIMARY_"`
+				Replica *DB `envPrefix:"REPLICA_DB_"`
+			}{EmbeddedStruct: &EmbeddedStruct{}, Replica: &DB{}}
+			err := p.Parse(obj)
+
+			So(err, ShouldBeNil)
+			So(obj.EmbeddedStruct.V, ShouldBeTrue)
+			So(obj.EmbeddedStruct.V2, ShouldEqual, 4)
+			So(obj.Primary.Host, ShouldEqual, "db1")
+			So(obj.Primary.Port, ShouldEqual, 5432)
+			So(obj.Replica.Host, ShouldEqual, "db2")
+			So(obj.Replica.Port, ShouldEqual, 5433)
+		})
+
+		Convey("Parses collections of structs from indexed env vars", func() {
+			p := Parser{LookupEnv: mapEnv(map[string]string{
+				"UPSTREAM_0_HOST": "a",
+				"UPSTREAM_0_PORT": "80",
+				"UPSTREAM_1_HOST": "b",
+				"UPSTREAM_3_HOST": "d",
+				"POOL_0_HOST":     "c",
+				"BAD_0_PORT":      "x",
+				"BAD_1_PORT":      "1",
+			})}
+			type Upstream struct {
+				Host string `env:"HOST,required"`
+				Port int    `env:"PORT" default:"8080"`
+			}
+
+			obj := &struct {
+				V1 []Upstream   `env:"UPSTREAM"`
+				V2 [3]*Upstream `env:"UPSTREAM"`
+				V3 []*Upstream  `env:"POOL"`
+				V4 []Upstream   `env:"NONE"`
+				V5 []Upstream
+			}{V4: []Upstream{{Host: "keep"}}}
+			err := p.Parse(obj)
+
+			So(err, ShouldBeNil)
+			So(obj.V1, ShouldResemble, []Upstream{
+				{Host: "a", Port: 80}, {Host: "b", Port: 8080},
+			})
+			So(*obj.V2[0], ShouldResemble, Upstream{Host: "a", Port: 80})
+			So(*obj.V2[1], ShouldResemble, Upstream{Host: "b", Port: 8080})
+			So(obj.V2[2], ShouldBeNil)
+			So(obj.V3, ShouldHaveLength, 1)
+			So(obj.V3[0].Host, ShouldEqual, "c")
+			So(obj.V4, ShouldResemble, []Upstream{{Host: "keep"}})
+			So(obj.V5, ShouldBeNil)
+
+			Convey("Reports failures with element index", func() {
+				p.CollectErrors = true
+				obj := &struct {
+					V []Upstream `env:"BAD"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldNotBeNil)
+				var parseErr ParseError
+				So(errors.As(err, &parseErr), ShouldBeTrue)
+				So(parseErr.Field, ShouldEqual, "V[0].Port")
+				So(parseErr.EnvVar, ShouldEqual, "BAD_0_PORT")
+				var missing MissingVarError
+				So(errors.As(err, &missing), ShouldBeTrue)
+				So(missing.Vars, ShouldResemble, []MissingVar{
+					{Field: "V[0].Host", EnvVar: "BAD_0_HOST"},
+					{Field: "V[1].Host", EnvVar: "BAD_1_HOST"},
+				})
+			})
+
+			Convey("Derives names with AutoNames", func() {
+				p.AutoNames = ScreamingSnakeNames
+				obj := &struct {
+					Upstream []struct {
+						Host string
+					}
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.Upstream, ShouldHaveLength, 2)
+				So(obj.Upstream[1].Host, ShouldEqual, "b")
+			})
+
+			Convey("Omits nested collections of recursive structs", func() {
+				p.LookupEnv = mapEnv(map[string]string{
+					"TREE_0_NAME":         "a",
+					"TREE_0_CHILD_0_NAME": "b",
+				})
+				obj := &struct {
+					V []tree `env:"TREE"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V, ShouldResemble, []tree{{Name: "a"}})
+			})
+		})
+
+		Convey("Parses maps of structs from keyed env vars", func() {
+			env := map[string]string{
+				"CACHE_USERS_TTL":          "1m",
+				"CACHE_USERS_SIZE":         "10",
+				"CACHE_USER_TOKENS_TTL":    "5s",
+				"CACHE_USER_TOKENS_TLS_ON": "true",
+				"CACHE_TTL":                "1s",
+				"CACHE_OTHER":              "x",
+				"QUEUE_1_WORKERS":          "2",
+				"QUEUE_X_WORKERS":          "3",
+			}
+			p := Parser{
+				LookupEnv: mapEnv(env),
+				Environ: func() []string {
+					var environ []string
+					for name, val := range env {
+						environ = append(environ, name+"="+val)
+					}
+					return environ
+				},
+			}
+			type Cache struct {
+				TTL  time.Duration `env:"TTL"`
+				Size int           `env:"SIZE" default:"100"`
+				TLS  struct {
+					On bool `env:"ON"`
+				} `envPrefix:"TLS_"`
+			}
+			type Queue struct {
+				Workers int `env:"WORKERS"`
+			}
+			obj := &struct {
+				V1 map[string]Cache  `env:"CACHE"`
+				V2 map[string]*Cache `env:"CACHE"`
+				V3 map[string]Cache  `env:"NONE"`
+				V4 map[string]Cache
+				V5 map[string]**Cache `env:"CACHE"`
+			}{V3: map[string]Cache{"keep": {}}}
+			err := p.Parse(obj)
+
+			So(err, ShouldBeNil)
+			So(obj.V1, ShouldHaveLength, 2)
+			So(obj.V1["USERS"].TTL, ShouldEqual, time.Minute)
+			So(obj.V1["USERS"].Size, ShouldEqual, 10)
+			So(obj.V1["USER_TOKENS"].TTL, ShouldEqual, 5*time.Second)
+			So(obj.V1["USER_TOKENS"].Size, ShouldEqual, 100)
+			So(obj.V1["USER_TOKENS"].TLS.On, ShouldBeTrue)
+			So(obj.V2["USERS"].TTL, ShouldEqual, time.Minute)
+			So((*obj.V5["USERS"]).TTL, ShouldEqual, time.Minute)
+			So(obj.V3, ShouldContainKey, "keep")
+			So(obj.V4, ShouldBeNil)
+
+			Convey("Only collects env var names of value struct", func() {
+				p.Environ = func() []string { return nil }
+				decoded := 0
+				RegisterFunc(&p, func(value string) (uint, error) {
+					decoded++
+					return 1, nil
+				})
+				validations = 0
+				obj := &struct {
+					V map[string]struct {
+						N countedValidation
+					} `env:"COUNTED"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V, ShouldBeNil)
+				So(decoded, ShouldEqual, 0)
+				So(validations, ShouldEqual, 0)
+			})
+
+			Convey("Parses maps of recursive structs", func() {
+				p := Parser{
+					LookupEnv: mapEnv(map[string]string{"NODE_A_NAME": "a"}),
+					Environ: func() []string {
+						return []string{"NODE_A_NAME=a"}
+					},
+				}
+				obj := &struct {
+					V1 map[string]node  `env:"NODE"`
+					V2 map[string]graph `env:"NODE"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V1, ShouldResemble, map[string]node{"A": {Name: "a"}})
+				So(obj.V2, ShouldResemble, map[string]graph{"A": {Name: "a"}})
+
+				Convey("Even if there are no env vars", func() {
+					p.Environ = func() []string { return nil }
+					obj := &struct {
+						V1 map[string]node  `env:"NODE"`
+						V2 map[string]graph `env:"NODE"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldBeNil)
+					So(obj.V1, ShouldBeNil)
+					So(obj.V2, ShouldBeNil)
+				})
+			})
+
+			Convey("Parses keys of other types", func() {
+				p.CollectErrors = true
+				obj := &struct {
+					V map[int]Queue `env:"QUEUE"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldNotBeNil)
+				So(err, ShouldHaveSameTypeAs, ParseErrors{})
+				So(err.(ParseErrors)[0].Field, ShouldEqual, "V[X]")
+				So(obj.V, ShouldResemble, map[int]Queue{1: {Workers: 2}})
+			})
+		})
+
+		Convey("Skips nil pointers to recursive structs", func() {
+			obj := &struct {
+				Head *node
+				Port int `env:"PORT"`
+				Req  *http.Request
+			}{}
+			err := p.Parse(obj)
+
+			So(err, ShouldBeNil)
+			So(obj.Head, ShouldBeNil)
+			So(obj.Req, ShouldBeNil)
+		})
+
 		Convey("Parses values for types behind pointers", func() {
 			setEnv("DEREF_BOOL", "true")
 			setEnv("DEREF_INT", "-10")
@@ -893,35 +1480,137 @@ func TestParser_Parse(t *testing.T) {
 			})
 		})
 
-		Convey("On tagged struct without custom parser", func() {
-			Convey("Returns error of unsupported type", func() {
-				setEnv("EMBEDDED_STRUCT", "{3}")
-				setEnv("EMBEDDED_BOOL", "true")
-				setEnv("EMBEDDED_INT", "-2")
+		Convey("Uses registered decoders", func() {
+			p := Parser{LookupEnv: mapEnv(map[string]string{
+				"DECODER_URL":    "http://localhost",
+				"DECODER_URLS":   "http://a,http://b",
+				"DECODER_MAP":    "http://a=seven",
+				"DECODER_UINT":   "seven",
+				"DECODER_PTR":    "http://c",
+				"DECODER_CUSTOM": "any",
+			})}
+			p.RegisterDecoder(refl.TypeOf(url.URL{}),
+				func(value string) (interface{}, error) {
+					u, err := url.Parse(value)
+					if err != nil {
+						return nil, err
+					}
+					return *u, nil
+				})
+			RegisterFunc(&p, func(value string) (uint, error) {
+				if value == "seven" {
+					return 7, nil
+				}
+				return 0, errors.New("not seven")
+			})
+			RegisterFunc(&p, func(value string) (customUint8, error) {
+				return 3, nil
+			})
+			obj := &struct {
+				V1 url.URL          `env:"DECODER_URL"`
+				V2 []*url.URL       `env:"DECODER_URLS"`
+				V3 map[url.URL]uint `env:"DECODER_MAP" kvsep:"="`
+				V4 uint             `env:"DECODER_UINT"`
+				V5 customUint8      `env:"DECODER_CUSTOM"`
+				V6 *url.URL         `env:"DECODER_PTR"`
+			}{}
+			err := p.Parse(obj)
+
+			So(err, ShouldBeNil)
+			So(obj.V1.Host, ShouldEqual, "localhost")
+			So(obj.V2, ShouldHaveLength, 2)
+			So(obj.V2[1].Host, ShouldEqual, "b")
+			So(obj.V3, ShouldResemble, map[url.URL]uint{
+				{Scheme: "http", Host: "a"}: 7,
+			})
+			So(obj.V4, ShouldEqual, 7)
+			So(obj.V5, ShouldEqual, 3)
+			So(obj.V6, ShouldNotBeNil)
+			So(obj.V6.Host, ShouldEqual, "c")
+
+			Convey("Takes priority over built-in parsing", func() {
 				obj := &struct {
-					V EmbeddedStruct `env:"EMBEDDED_STRUCT"`
+					V uint `env:"DECODER_PTR"`
 				}{}
 				err := p.Parse(obj)
 
 				So(err, ShouldNotBeNil)
-				So(err, ShouldHaveSameTypeAs, UnparsableTypeError{})
+				So(err.Error(), ShouldContainSubstring, "not seven")
 			})
-		})
 
-		Convey("If value cannot be parsed", func() {
-			Convey("Returns parsing error", func() {
-				Convey("supported types", func() {
-					setEnv("FAIL_BOOL", "hi")
-					setEnv("FAIL_INT", "true")
-					setEnv("FAIL_UINT", "false")
-					setEnv("FAIL_FLOAT", "-----")
-					setEnv("FAIL_DURATION", "???")
-					obj1 := &struct {
-						V bool `env:"FAIL_BOOL"`
-					}{}
-					obj2 := &struct {
-						V int `env:"FAIL_INT"`
-					}{}
+			Convey("Decodes pointers if registered for pointer type", func() {
+				RegisterFunc(&p, func(value string) (*url.URL, error) {
+					return &url.URL{Host: "registered"}, nil
+				})
+				obj := &struct {
+					V *url.URL `env:"DECODER_PTR"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V, ShouldNotBeNil)
+				So(obj.V.Host, ShouldEqual, "registered")
+			})
+
+			Convey("Does not affect copies of parser", func() {
+				p2 := p
+				RegisterFunc(&p2, func(value string) (uint, error) {
+					return 1, nil
+				})
+				obj := &struct {
+					V uint `env:"DECODER_UINT"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V, ShouldEqual, 7)
+			})
+
+			Convey("Returns error if decoder returns value of wrong type",
+				func() {
+					p.RegisterDecoder(refl.TypeOf(0),
+						func(value string) (interface{}, error) {
+							return "str", nil
+						})
+					obj := &struct {
+						V int `env:"DECODER_URL"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldNotBeNil)
+					So(err, ShouldHaveSameTypeAs, ParseError{})
+				})
+		})
+
+		Convey("On tagged struct without custom parser", func() {
+			Convey("Returns error of unsupported type", func() {
+				setEnv("EMBEDDED_STRUCT", "{3}")
+				setEnv("EMBEDDED_BOOL", "true")
+				setEnv("EMBEDDED_INT", "-2")
+				obj := &struct {
+					V EmbeddedStruct `env:"EMBEDDED_STRUCT"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldNotBeNil)
+				So(err, ShouldHaveSameTypeAs, UnparsableTypeError{})
+			})
+		})
+
+		Convey("If value cannot be parsed", func() {
+			Convey("Returns parsing error", func() {
+				Convey("supported types", func() {
+					setEnv("FAIL_BOOL", "hi")
+					setEnv("FAIL_INT", "true")
+					setEnv("FAIL_UINT", "false")
+					setEnv("FAIL_FLOAT", "-----")
+					setEnv("FAIL_DURATION", "???")
+					obj1 := &struct {
+						V bool `env:"FAIL_BOOL"`
+					}{}
+					obj2 := &struct {
+						V int `env:"FAIL_INT"`
+					}{}
 					obj3 := &struct {
 						V uint16 `env:"FAIL_UINT"`
 					}{}
@@ -954,6 +1643,56 @@ func TestParser_Parse(t *testing.T) {
 					So(err5.Error(), ShouldContainSubstring, "'FAIL_DURATION'")
 				})
 
+				Convey("maps", func() {
+					setEnv("FAIL_MAP_FORMAT", "a:1,b")
+					setEnv("FAIL_MAP_VALUE", "a:1,b:c")
+					obj1 := &struct {
+						V map[string]int `env:"FAIL_MAP_FORMAT"`
+					}{}
+					obj2 := &struct {
+						V map[string]int `env:"FAIL_MAP_VALUE"`
+					}{}
+					err1 := p.Parse(obj1)
+					err2 := p.Parse(obj2)
+
+					So(err1, ShouldNotBeNil)
+					So(err1, ShouldHaveSameTypeAs, ParseError{})
+					So(err1.Error(), ShouldContainSubstring, "'b'")
+					So(err2, ShouldNotBeNil)
+					So(err2, ShouldHaveSameTypeAs, ParseError{})
+					So(obj1.V, ShouldBeNil)
+					So(obj2.V, ShouldBeNil)
+					var elemErr ElementError
+					So(errors.As(err2, &elemErr), ShouldBeTrue)
+					So(elemErr.Index, ShouldEqual, 1)
+					So(elemErr.Value, ShouldEqual, "b:c")
+				})
+
+				Convey("collection elements", func() {
+					setEnv("FAIL_PORTS", "80,443,8080,80a,90")
+					obj1 := &struct {
+						Before int
+						V      [5]uint16 `env:"FAIL_PORTS"`
+					}{}
+					obj2 := &struct {
+						Before int
+						V      []uint16 `env:"FAIL_PORTS"`
+					}{}
+					err1 := p.Parse(obj1)
+					err2 := p.Parse(obj2)
+
+					for _, err := range []error{err1, err2} {
+						So(err, ShouldNotBeNil)
+						So(err, ShouldHaveSameTypeAs, ParseError{})
+						So(err.(ParseError).Field, ShouldEqual, "V")
+						So(err.Error(), ShouldContainSubstring, "[3]")
+						var elemErr ElementError
+						So(errors.As(err, &elemErr), ShouldBeTrue)
+						So(elemErr.Index, ShouldEqual, 3)
+						So(elemErr.Value, ShouldEqual, "80a")
+					}
+				})
+
 				Convey("custom parser type", func() {
 					setEnv("FAIL_CUSTOM", "10")
 					v := customFailure(4)
@@ -996,6 +1735,887 @@ func TestParser_Parse(t *testing.T) {
 					So(err2, ShouldHaveSameTypeAs, ParseError{})
 				})
 			})
+
+			Convey("Describes failure completely", func() {
+				setEnv("DB_FAIL_PORT", "80a")
+				obj := &struct {
+					Server struct {
+						DB struct {
+							Port int `env:"FAIL_PORT"`
+						} `envPrefix:"DB_"`
+					}
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldNotBeNil)
+				So(err, ShouldHaveSameTypeAs, ParseError{})
+				perr := err.(ParseError)
+				So(perr.Field, ShouldEqual, "Server.DB.Port")
+				So(perr.EnvVar, ShouldEqual, "DB_FAIL_PORT")
+				So(perr.Value, ShouldEqual, "80a")
+				var numErr *strconv.NumError
+				So(errors.As(err, &numErr), ShouldBeTrue)
+				So(numErr.Num, ShouldEqual, "80a")
+			})
+		})
+
+		Convey("Validates parsed values", func() {
+			p := Parser{LookupEnv: mapEnv(map[string]string{
+				"VALID_PORT":     "8080",
+				"VALID_TIMEOUT":  "5s",
+				"VALID_RATIO":    "0.5",
+				"VALID_NAME":     "core",
+				"VALID_LEVEL":    "info",
+				"VALID_CODES":    "1,3",
+				"VALID_KEY":      "abcd",
+				"VALID_URL":      "https://example.com/api",
+				"VALID_URLS":     "http://a,file:///b",
+				"VALID_TAGS":     "a:x,b:y",
+				"VALID_IP":       "127.0.0.1",
+				"INVALID_PORT":   "0",
+				"INVALID_LEVEL":  "trace",
+				"INVALID_EMPTY":  "",
+				"INVALID_URL":    "example.com",
+				"INVALID_CODES":  "1,4",
+				"INVALID_REGEXP": "ABCD",
+			})}
+
+			Convey("Accepts valid values", func() {
+				obj := &struct {
+					V1  uint16            `env:"VALID_PORT" min:"1" max:"65535"`
+					V2  time.Duration     `env:"VALID_TIMEOUT" min:"1s" max:"1m"`
+					V3  float64           `env:"VALID_RATIO" min:"0" max:"1"`
+					V4  string            `env:"VALID_NAME,nonempty" min:"2" max:"8"`
+					V5  string            `env:"VALID_LEVEL" oneof:"debug info warn"`
+					V6  []int             `env:"VALID_CODES" oneof:"1 2 3" len:"2"`
+					V7  *string           `env:"VALID_KEY" len:"4" regexp:"^[a-f]+$"`
+					V8  string            `env:"VALID_URL,url"`
+					V9  []string          `env:"VALID_URLS,url,nonempty"`
+					V10 map[string]string `env:"VALID_TAGS" oneof:"x y" max:"2"`
+					V11 net.IP            `env:"VALID_IP" oneof:"127.0.0.1 ::1"`
+					V12 int               `env:"VALID_UNSET,nonempty" min:"1"`
+					V13 int               `env:"VALID_DEFAULT" default:"3" max:"5"`
+				}{V7: new(string)}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V1, ShouldEqual, 8080)
+				So(*obj.V7, ShouldEqual, "abcd")
+				So(obj.V13, ShouldEqual, 3)
+			})
+
+			Convey("Returns error on invalid value", func() {
+				intType := refl.TypeOf(0)
+				strType := refl.TypeOf("")
+				for _, c := range []struct {
+					tag  string
+					typ  refl.Type
+					rule string
+				}{
+					{`env:"INVALID_PORT" min:"1"`, intType, "min"},
+					{`env:"VALID_PORT" max:"1024"`, intType, "max"},
+					{`env:"VALID_TIMEOUT" max:"1s"`,
+						refl.TypeOf(time.Second), "max"},
+					{`env:"VALID_NAME" min:"5"`, strType, "min"},
+					{`env:"INVALID_LEVEL" oneof:"debug info"`, strType, "oneof"},
+					{`env:"INVALID_EMPTY,nonempty"`, strType, "nonempty"},
+					{`env:"INVALID_PORT,nonempty"`, intType, "nonempty"},
+					{`env:"INVALID_URL,url"`, strType, "url"},
+					{`env:"INVALID_REGEXP" regexp:"^[a-f]+$"`, strType, "regexp"},
+					{`env:"VALID_NAME" len:"3"`, strType, "len"},
+					{`env:"INVALID_CODES" oneof:"1 2 3"`,
+						refl.TypeOf([]int{}), "oneof"},
+					{`env:"VALID_DEFAULT" default:"6" max:"5"`, intType, "max"},
+				} {
+					obj := refl.New(refl.StructOf([]refl.StructField{{
+						Name: "V", Type: c.typ, Tag: refl.StructTag(c.tag),
+					}}))
+					err := p.Parse(obj.Interface())
+
+					So(err, ShouldNotBeNil)
+					So(err, ShouldHaveSameTypeAs, ValidationError{})
+					So(err.(ValidationError).Field, ShouldEqual, "V")
+					So(err.(ValidationError).Rule, ShouldEqual, c.rule)
+				}
+			})
+
+			Convey("Never reports invalid value", func() {
+				obj := &struct {
+					V string `env:"INVALID_LEVEL" oneof:"debug info"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldNotBeNil)
+				So(err.(ValidationError).EnvVar, ShouldEqual, "INVALID_LEVEL")
+				So(err.Error(), ShouldNotContainSubstring, "trace")
+			})
+
+			Convey("Collects validation errors", func() {
+				p.CollectErrors = true
+				obj := &struct {
+					V1 int    `env:"INVALID_PORT" min:"1"`
+					V2 string `env:"INVALID_LEVEL" oneof:"debug info"`
+					V3 int    `env:"INVALID_LEVEL"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldNotBeNil)
+				var errs ParseErrors
+				So(errors.As(err, &errs), ShouldBeTrue)
+				So(errs, ShouldHaveLength, 1)
+				var validErr ValidationError
+				So(errors.As(err, &validErr), ShouldBeTrue)
+				So(validErr.Field, ShouldEqual, "V1")
+				So(err.Error(), ShouldContainSubstring, "'V2'")
+			})
+
+			Convey("Returns error on malformed rule", func() {
+				for _, tag := range []string{
+					`env:"VALID_PORT" min:"x"`,
+					`env:"VALID_PORT" len:"1"`,
+					`env:"VALID_PORT,url"`,
+					`env:"VALID_PORT" oneof:"a b"`,
+					`env:"VALID_PORT" regexp:"("`,
+				} {
+					obj := refl.New(refl.StructOf([]refl.StructField{{
+						Name: "V", Type: refl.TypeOf(0), Tag: refl.StructTag(tag),
+					}}))
+					err := p.Parse(obj.Interface())
+
+					So(err, ShouldNotBeNil)
+					So(err, ShouldHaveSameTypeAs, InvalidTagError{})
+				}
+			})
+		})
+
+		Convey("Validates structs implementing Validator", func() {
+			p := Parser{LookupEnv: mapEnv(map[string]string{
+				"TLS_CERT":   "cert.pem",
+				"TLS_KEY":    "key.pem",
+				"WORKERS":    "0",
+				"SELF_VALID": "true",
+			})}
+
+			Convey("Accepts valid structs", func() {
+				obj := &validatedConfig{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.validated, ShouldBeTrue)
+				So(obj.Server.TLS.validated, ShouldBeTrue)
+				So(obj.Server.Opt, ShouldBeNil)
+			})
+
+			Convey("Returns error with struct path", func() {
+				p.LookupEnv = mapEnv(map[string]string{"TLS_CERT": "c"})
+				obj := &validatedConfig{}
+				err := p.Parse(obj)
+
+				So(err, ShouldNotBeNil)
+				So(err, ShouldHaveSameTypeAs, StructValidationError{})
+				So(err.(StructValidationError).Struct, ShouldEqual, "Server.TLS")
+				So(errors.Is(err, errKeyRequired), ShouldBeTrue)
+				So(obj.validated, ShouldBeFalse)
+			})
+
+			Convey("Collects errors of all structs", func() {
+				p.CollectErrors = true
+				p.LookupEnv = mapEnv(map[string]string{
+					"TLS_CERT": "c",
+					"WORKERS":  "-1",
+				})
+				obj := &validatedConfig{}
+				err := p.Parse(obj)
+
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "'Server.TLS'")
+				So(err.Error(), ShouldContainSubstring, "workers")
+			})
+
+			Convey("Omits validation of not parsed structs", func() {
+				p.CollectErrors = true
+				p.LookupEnv = mapEnv(map[string]string{
+					"TLS_CERT": "c",
+					"TLS_KEY":  "k",
+					"WORKERS":  "x",
+				})
+				obj := &validatedConfig{}
+				err := p.Parse(obj)
+
+				So(err, ShouldNotBeNil)
+				So(err, ShouldHaveSameTypeAs, ParseErrors{})
+				So(obj.Server.TLS.validated, ShouldBeTrue)
+				So(obj.validated, ShouldBeFalse)
+			})
+		})
+
+		Convey("Respects parser options", func() {
+			Convey("Prefix", func() {
+				setEnv("MYAPP_PREFIXED_INT", "3")
+				setEnv("MYAPP_NESTED_PREFIXED_INT", "4")
+				p := Parser{Prefix: "MYAPP_"}
+				obj := &struct {
+					V int `env:"PREFIXED_INT"`
+					N struct {
+						V int `env:"PREFIXED_INT"`
+					} `envPrefix:"NESTED_"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V, ShouldEqual, 3)
+				So(obj.N.V, ShouldEqual, 4)
+			})
+
+			Convey("TagName", func() {
+				setEnv("CONFIG_INT", "5")
+				setEnv("ENV_INT", "6")
+				p := Parser{TagName: "config"}
+				obj := &struct {
+					V1 int `config:"CONFIG_INT"`
+					V2 int `env:"ENV_INT"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V1, ShouldEqual, 5)
+				So(obj.V2, ShouldEqual, 0)
+			})
+
+			Convey("LookupEnv", func() {
+				unsetEnv("LOOKUP_INT")
+				p := Parser{LookupEnv: mapEnv(map[string]string{
+					"LOOKUP_INT": "7",
+				})}
+				obj := &struct {
+					V1 int `env:"LOOKUP_INT"`
+					V2 int `env:"LOOKUP_MISSING" default:"8"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V1, ShouldEqual, 7)
+				So(obj.V2, ShouldEqual, 8)
+			})
+
+			Convey("RedactValues", func() {
+				p := Parser{RedactValues: true, LookupEnv: mapEnv(
+					map[string]string{"REDACT_INT": "s3cr3t"},
+				)}
+				obj := &struct {
+					V int `env:"REDACT_INT"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldNotContainSubstring, "s3cr3t")
+				So(err.(ParseError).Value, ShouldBeEmpty)
+				So(errors.Is(err, strconv.ErrSyntax), ShouldBeTrue)
+				var numErr *strconv.NumError
+				So(errors.As(err, &numErr), ShouldBeFalse)
+			})
+
+			Convey("RedactValues of collection elements", func() {
+				p := Parser{RedactValues: true, LookupEnv: mapEnv(
+					map[string]string{"REDACT_SLICE": "1,s3cr3t"},
+				)}
+				obj := &struct {
+					V []int `env:"REDACT_SLICE"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldNotContainSubstring, "s3cr3t")
+				var elemErr ElementError
+				So(errors.As(err, &elemErr), ShouldBeTrue)
+				So(elemErr.Index, ShouldEqual, 1)
+				So(elemErr.Value, ShouldBeEmpty)
+			})
+
+			Convey("RedactValues of `secret` fields", func() {
+				p := Parser{CollectErrors: true, LookupEnv: mapEnv(
+					map[string]string{
+						"SECRET_INT": "s3cr3t",
+						"PLAIN_INT":  "pl41n",
+					},
+				)}
+				obj := &struct {
+					V1 int   `env:"SECRET_INT,secret"`
+					V2 []int `env:"SECRET_INT,secret"`
+					V3 int   `env:"PLAIN_INT"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldNotContainSubstring, "s3cr3t")
+				So(err.Error(), ShouldContainSubstring, "pl41n")
+				errs := err.(ParseErrors)
+				So(errs, ShouldHaveLength, 3)
+				So(errs[0].Value, ShouldBeEmpty)
+				So(errs[1].Value, ShouldBeEmpty)
+				So(errs[2].Value, ShouldEqual, "pl41n")
+
+				Convey("Even if they are quoted in error", func() {
+					p.LookupEnv = mapEnv(map[string]string{
+						"SECRET_PIN": "p\"w\x01",
+						"SECRET_URL": "http://[::1]:p\"w\x01",
+					})
+					obj := &struct {
+						V1 int      `env:"SECRET_PIN,secret"`
+						V2 *url.URL `env:"SECRET_URL,secret"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldNotContainSubstring, `p\"w`)
+					So(err.Error(), ShouldNotContainSubstring, `\x01`)
+					So(err.Error(), ShouldNotContainSubstring, "p\"w\x01")
+				})
+
+				Convey("Even if they fail to expand", func() {
+					p.LookupEnv = mapEnv(map[string]string{
+						"SECRET_PW": "hunter2${oops",
+					})
+					obj := &struct {
+						V string `env:"SECRET_PW,secret,expand"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldNotContainSubstring, "hunter2")
+					So(err.Error(), ShouldNotContainSubstring, "oops")
+					So(errors.Is(err, errUnterminatedRef), ShouldBeTrue)
+				})
+
+				Convey("Even in their underlying causes", func() {
+					p.LookupEnv = mapEnv(map[string]string{
+						"SECRET_INT": "12a34",
+					})
+					obj := &struct {
+						V int `env:"SECRET_INT,secret"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldNotBeNil)
+					So(errors.Is(err, strconv.ErrSyntax), ShouldBeTrue)
+					var numErr *strconv.NumError
+					So(errors.As(err, &numErr), ShouldBeFalse)
+				})
+			})
+
+			Convey("AllocPointers", func() {
+				p := Parser{AllocPointers: true, LookupEnv: mapEnv(
+					map[string]string{
+						"ALLOC_INT":      "0",
+						"ALLOC_DURATION": "3s",
+						"ALLOC_NESTED":   "true",
+						"ALLOC_FAIL":     "?",
+					},
+				)}
+				type Nested struct {
+					V bool `env:"ALLOC_NESTED"`
+				}
+				type Unset struct {
+					V bool `env:"ALLOC_UNSET"`
+					W int  `env:"ALLOC_DEFAULT" default:"1"`
+				}
+				obj := &struct {
+					V1 *int            `env:"ALLOC_INT"`
+					V2 **time.Duration `env:"ALLOC_DURATION"`
+					V3 *string         `env:"ALLOC_UNSET"`
+					V4 *uint           `env:"ALLOC_DEFAULT" default:"2"`
+					N1 *Nested
+					N2 **Nested
+					N3 *Unset
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V1, ShouldNotBeNil)
+				So(*obj.V1, ShouldEqual, 0)
+				So(obj.V2, ShouldNotBeNil)
+				So(*obj.V2, ShouldNotBeNil)
+				So(**obj.V2, ShouldEqual, 3*time.Second)
+				So(obj.V3, ShouldBeNil)
+				So(obj.V4, ShouldNotBeNil)
+				So(*obj.V4, ShouldEqual, 2)
+				So(obj.N1, ShouldNotBeNil)
+				So(obj.N1.V, ShouldBeTrue)
+				So(obj.N2, ShouldNotBeNil)
+				So((*obj.N2).V, ShouldBeTrue)
+				So(obj.N3, ShouldBeNil)
+
+				Convey("Does not allocate on failure", func() {
+					p.CollectErrors = true
+					obj := &struct {
+						V *int `env:"ALLOC_FAIL"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldNotBeNil)
+					So(obj.V, ShouldBeNil)
+				})
+
+				Convey("Does not allocate recursive structs infinitely", func() {
+					p.LookupEnv = mapEnv(map[string]string{"HEAD_NAME": "a"})
+					obj := &struct {
+						Head *node `envPrefix:"HEAD_"`
+						Tail *node `envPrefix:"TAIL_"`
+						Req  *http.Request
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldBeNil)
+					So(obj.Head, ShouldNotBeNil)
+					So(obj.Head.Name, ShouldEqual, "a")
+					So(obj.Head.Next, ShouldBeNil)
+					So(obj.Tail, ShouldBeNil)
+					So(obj.Req, ShouldBeNil)
+				})
+			})
+
+			Convey("AutoNames", func() {
+				p := Parser{AutoNames: ScreamingSnakeNames, LookupEnv: mapEnv(
+					map[string]string{
+						"WORKERS_COUNT":        "4",
+						"SERVER_READ_TIMEOUT":  "5s",
+						"SERVER_STARTED_AT":    "2017-01-02T03:04:05Z",
+						"SERVER_TLS_CERT_FILE": "cert.pem",
+						"DB_HOST":              "db",
+						"DB_PORT":              "5432",
+						"TAGGED":               "true",
+					},
+				)}
+				type DB struct {
+					Host string
+					Port int    `env:",required"`
+					User string `default:"admin"`
+				}
+				obj := &struct {
+					WorkersCount int
+					Server       struct {
+						ReadTimeout time.Duration
+						StartedAt   time.Time
+						TLS         *struct {
+							CertFile string
+						}
+					}
+					Postgres DB   `envPrefix:"DB_"`
+					V        bool `env:"TAGGED"`
+					h        int  // nolint: unused, megacheck
+				}{}
+				obj.Server.TLS = &struct{ CertFile string }{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.WorkersCount, ShouldEqual, 4)
+				So(obj.Server.ReadTimeout, ShouldEqual, 5*time.Second)
+				So(obj.Server.StartedAt.Year(), ShouldEqual, 2017)
+				So(obj.Server.TLS.CertFile, ShouldEqual, "cert.pem")
+				So(obj.Postgres.Host, ShouldEqual, "db")
+				So(obj.Postgres.Port, ShouldEqual, 5432)
+				So(obj.Postgres.User, ShouldEqual, "admin")
+				So(obj.V, ShouldBeTrue)
+
+				Convey("Adds no segment for inlined structs", func() {
+					p.LookupEnv = mapEnv(map[string]string{
+						"HOST":         "localhost",
+						"SERVER_PORT":  "80",
+						"SERVER_DEBUG": "true",
+					})
+					type Common struct {
+						Host string
+					}
+					type Debug struct {
+						Debug bool
+					}
+					obj := &struct {
+						Common `env:",inline"`
+						Server struct {
+							Port  int
+							Debug `env:",squash"`
+						}
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldBeNil)
+					So(obj.Host, ShouldEqual, "localhost")
+					So(obj.Server.Port, ShouldEqual, 80)
+					So(obj.Server.Debug.Debug, ShouldBeTrue)
+				})
+
+				Convey("Uses custom naming strategy", func() {
+					p.AutoNames = func(path []string) string {
+						return "APP_" + AsIsNames(path)
+					}
+					p.LookupEnv = mapEnv(map[string]string{
+						"APP_Server_Port": "80",
+					})
+					obj := &struct {
+						Server struct {
+							Port int
+						}
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldBeNil)
+					So(obj.Server.Port, ShouldEqual, 80)
+				})
+
+				Convey("Reports derived names of missing env vars", func() {
+					p.LookupEnv = mapEnv(map[string]string{})
+					obj := &struct {
+						Postgres DB `envPrefix:"DB_"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldNotBeNil)
+					So(err, ShouldHaveSameTypeAs, MissingVarError{})
+					So(err.(MissingVarError).Vars, ShouldResemble, []MissingVar{
+						{Field: "Postgres.Port", EnvVar: "DB_PORT"},
+					})
+				})
+			})
+
+			Convey("ReadFiles", func() {
+				dir, err := os.MkdirTemp("", "envigo")
+				So(err, ShouldBeNil)
+				Reset(func() { os.RemoveAll(dir) }) // nolint: errcheck
+				secret := filepath.Join(dir, "secret")
+				So(os.WriteFile(secret, []byte("s3cr3t\n"), 0600), ShouldBeNil)
+				p := Parser{ReadFiles: true, LookupEnv: mapEnv(
+					map[string]string{
+						"FILE_SECRET_FILE":  secret,
+						"FILE_DIRECT":       "direct",
+						"FILE_DIRECT_FILE":  secret,
+						"FILE_MISSING_FILE": filepath.Join(dir, "missing"),
+					},
+				)}
+				obj := &struct {
+					V1 string `env:"FILE_SECRET"`
+					V2 string `env:"FILE_DIRECT"`
+					V3 string `env:"FILE_UNSET" default:"default"`
+				}{}
+				err = p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V1, ShouldEqual, "s3cr3t\n")
+				So(obj.V2, ShouldEqual, "direct")
+				So(obj.V3, ShouldEqual, "default")
+
+				Convey("Trims trailing newline", func() {
+					p.TrimFileNewline = true
+					obj := &struct {
+						V string `env:"FILE_SECRET"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldBeNil)
+					So(obj.V, ShouldEqual, "s3cr3t")
+				})
+
+				Convey("Is enabled per field with `file` option", func() {
+					p.ReadFiles = false
+					obj := &struct {
+						V1 string `env:"FILE_SECRET,file"`
+						V2 string `env:"FILE_SECRET"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldBeNil)
+					So(obj.V1, ShouldEqual, "s3cr3t\n")
+					So(obj.V2, ShouldBeEmpty)
+				})
+
+				Convey("Returns error if file cannot be read", func() {
+					obj := &struct {
+						V string `env:"FILE_MISSING"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldNotBeNil)
+					So(err, ShouldHaveSameTypeAs, ParseError{})
+					So(err.(ParseError).EnvVar, ShouldEqual, "FILE_MISSING_FILE")
+					So(errors.Is(err, os.ErrNotExist), ShouldBeTrue)
+				})
+			})
+
+			Convey("ExpandValues", func() {
+				p := Parser{ExpandValues: true, Prefix: "APP_", LookupEnv: mapEnv(
+					map[string]string{
+						"HOME":         "/home/user",
+						"APP_DATA_DIR": "$HOME/data",
+						"APP_URL":      "http://${APP_HOST}:${APP_PORT:-8080}",
+						"APP_HOST":     "localhost",
+						"APP_CYCLE":    "${APP_CYCLE}",
+					},
+				)}
+				obj := &struct {
+					V1 string `env:"DATA_DIR"`
+					V2 string `env:"URL"`
+					V3 string `env:"CACHE_DIR" default:"${HOME}/cache"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V1, ShouldEqual, "/home/user/data")
+				So(obj.V2, ShouldEqual, "http://localhost:8080")
+				So(obj.V3, ShouldEqual, "/home/user/cache")
+
+				Convey("Is enabled per field with `expand` option", func() {
+					p.ExpandValues = false
+					obj := &struct {
+						V1 string `env:"DATA_DIR,expand"`
+						V2 string `env:"DATA_DIR"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldBeNil)
+					So(obj.V1, ShouldEqual, "/home/user/data")
+					So(obj.V2, ShouldEqual, "$HOME/data")
+				})
+
+				Convey("Returns error on cycle", func() {
+					obj := &struct {
+						V string `env:"CYCLE"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldNotBeNil)
+					So(err, ShouldHaveSameTypeAs, ParseError{})
+					So(err.(ParseError).EnvVar, ShouldEqual, "APP_CYCLE")
+					So(err.Error(), ShouldContainSubstring, "cycle")
+				})
+			})
+
+			Convey("Strict", func() {
+				env := map[string]string{
+					"MYAPP_HOST":         "localhost",
+					"MYAPP_WORKER_COUNT": "4",
+					"MYAPP_DEBUG":        "true",
+					"MYAPP_DB_0_URL":     "db",
+					"MYAPP_SECRET_FILE":  "/dev/null",
+					"OTHER_VAR":          "1",
+				}
+				p := Parser{
+					Strict:    true,
+					Prefix:    "MYAPP_",
+					ReadFiles: true,
+					LookupEnv: mapEnv(env),
+					Environ: func() []string {
+						var environ []string
+						for name, val := range env {
+							environ = append(environ, name+"="+val)
+						}
+						return environ
+					},
+				}
+				obj := &struct {
+					Host    string `env:"HOST"`
+					Workers int    `env:"WORKERS_COUNT"`
+					Secret  string `env:"SECRET"`
+					DB      []struct {
+						URL string `env:"URL"`
+					} `env:"DB"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldNotBeNil)
+				So(err, ShouldHaveSameTypeAs, UnknownVarError{})
+				So(err.(UnknownVarError).Vars, ShouldResemble, []UnknownVar{
+					{EnvVar: "MYAPP_DEBUG"},
+					{
+						EnvVar:     "MYAPP_WORKER_COUNT",
+						Suggestion: "MYAPP_WORKERS_COUNT",
+					},
+				})
+				So(obj.Host, ShouldEqual, "localhost")
+				So(obj.DB, ShouldHaveLength, 1)
+
+				Convey("Has no effect without prefix", func() {
+					p.Prefix = ""
+					obj := &struct {
+						V string `env:"MYAPP_HOST"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldBeNil)
+				})
+			})
+
+			Convey("UnsetVars", func() {
+				setEnv("UNSET_V1", "1")
+				setEnv("UNSET_V2", "2")
+				setEnv("UNSET_V3", "3")
+				p := Parser{UnsetVars: true}
+				obj := &struct {
+					V1 int `env:"UNSET_V1"`
+					V2 int `env:"UNSET_V2"`
+					V3 int `env:"UNSET_MISSING" default:"3"`
+				}{}
+				err := p.Parse(obj)
+
+				So(err, ShouldBeNil)
+				So(obj.V1, ShouldEqual, 1)
+				So(obj.V2, ShouldEqual, 2)
+				_, ok := os.LookupEnv("UNSET_V1")
+				So(ok, ShouldBeFalse)
+				_, ok = os.LookupEnv("UNSET_V2")
+				So(ok, ShouldBeFalse)
+				_, ok = os.LookupEnv("UNSET_V3")
+				So(ok, ShouldBeTrue)
+
+				Convey("Is enabled per field with `unset` option", func() {
+					setEnv("UNSET_V1", "1")
+					p.UnsetVars = false
+					obj := &struct {
+						V1 int `env:"UNSET_V1,unset"`
+						V3 int `env:"UNSET_V3"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldBeNil)
+					So(obj.V1, ShouldEqual, 1)
+					So(obj.V3, ShouldEqual, 3)
+					_, ok := os.LookupEnv("UNSET_V1")
+					So(ok, ShouldBeFalse)
+					_, ok = os.LookupEnv("UNSET_V3")
+					So(ok, ShouldBeTrue)
+				})
+
+				Convey("Uses UnsetEnv", func() {
+					env := map[string]string{"UNSET_MAP": "1"}
+					p.LookupEnv = mapEnv(env)
+					p.UnsetEnv = func(name string) error {
+						delete(env, name)
+						return nil
+					}
+					obj := &struct {
+						V int `env:"UNSET_MAP"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldBeNil)
+					So(obj.V, ShouldEqual, 1)
+					So(env, ShouldBeEmpty)
+				})
+
+				Convey("Keeps env vars of nil pointers", func() {
+					setEnv("UNSET_V1", "5s")
+					obj := &struct {
+						V *time.Duration `env:"UNSET_V1,unset"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldBeNil)
+					So(obj.V, ShouldBeNil)
+					val, ok := os.LookupEnv("UNSET_V1")
+					So(ok, ShouldBeTrue)
+					So(val, ShouldEqual, "5s")
+				})
+
+				Convey("Keeps env vars failed to parse", func() {
+					setEnv("UNSET_V1", "one")
+					setEnv("UNSET_V2", "2")
+					p.CollectErrors = true
+					obj := &struct {
+						V1 int `env:"UNSET_V1"`
+						V2 int `env:"UNSET_V2" min:"4"`
+						V3 int `env:"UNSET_V3"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldNotBeNil)
+					So(obj.V3, ShouldEqual, 3)
+					val, ok := os.LookupEnv("UNSET_V1")
+					So(ok, ShouldBeTrue)
+					So(val, ShouldEqual, "one")
+					_, ok = os.LookupEnv("UNSET_V2")
+					So(ok, ShouldBeTrue)
+					_, ok = os.LookupEnv("UNSET_V3")
+					So(ok, ShouldBeFalse)
+				})
+
+				Convey("Returns error if env var cannot be unset", func() {
+					setEnv("UNSET_V1", "1")
+					p.UnsetEnv = func(string) error {
+						return errors.New("read-only")
+					}
+					obj := &struct {
+						V int `env:"UNSET_V1"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldContainSubstring, "UNSET_V1")
+					So(err.Error(), ShouldContainSubstring, "read-only")
+				})
+
+				Reset(func() {
+					unsetEnv("UNSET_V1")
+					unsetEnv("UNSET_V2")
+					unsetEnv("UNSET_V3")
+				})
+			})
+
+			Convey("CollectErrors", func() {
+				p := Parser{CollectErrors: true, LookupEnv: mapEnv(
+					map[string]string{
+						"COLLECT_INT":    "one",
+						"COLLECT_BOOL":   "true",
+						"COLLECT_NESTED": "-",
+						"COLLECT_UINT":   "-1",
+					},
+				)}
+
+				Convey("Returns all parsing errors", func() {
+					obj := &struct {
+						V1 int  `env:"COLLECT_INT"`
+						V2 bool `env:"COLLECT_BOOL"`
+						N  struct {
+							V float32 `env:"COLLECT_NESTED"`
+						}
+						V3 uint `env:"COLLECT_UINT"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldNotBeNil)
+					So(err, ShouldHaveSameTypeAs, ParseErrors{})
+					errs := err.(ParseErrors)
+					So(errs, ShouldHaveLength, 3)
+					So(errs[0].EnvVar, ShouldEqual, "COLLECT_INT")
+					So(errs[1].EnvVar, ShouldEqual, "COLLECT_NESTED")
+					So(errs[2].EnvVar, ShouldEqual, "COLLECT_UINT")
+					So(obj.V2, ShouldBeTrue)
+				})
+
+				Convey("Joins parsing errors with missing env vars", func() {
+					obj := &struct {
+						V1 int `env:"COLLECT_INT"`
+						V2 int `env:"COLLECT_MISSING,required"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldNotBeNil)
+					var errs ParseErrors
+					So(errors.As(err, &errs), ShouldBeTrue)
+					So(errs, ShouldHaveLength, 1)
+					var missing MissingVarError
+					So(errors.As(err, &missing), ShouldBeTrue)
+					So(missing.Vars, ShouldHaveLength, 1)
+				})
+
+				Convey("Aborts on unrecoverable error", func() {
+					obj := &struct {
+						V1 int     `env:"COLLECT_INT"`
+						V2 uintptr `env:"COLLECT_BOOL"`
+					}{}
+					err := p.Parse(obj)
+
+					So(err, ShouldNotBeNil)
+					So(err, ShouldHaveSameTypeAs, UnparsableTypeError{})
+				})
+			})
 		})
 	})
 }
@@ -1013,6 +2633,69 @@ func (_ *customFailure) UnmarshalText(_ []byte) error {
 	return errors.New("some error")
 }
 
+var errKeyRequired = errors.New("TLS key required when TLS cert is set")
+
+type validatedTLS struct {
+	Cert      string `env:"TLS_CERT"`
+	Key       string `env:"TLS_KEY"`
+	validated bool
+}
+
+func (c *validatedTLS) Validate() error {
+	if c.Cert != "" && c.Key == "" {
+		return errKeyRequired
+	}
+	c.validated = true
+	return nil
+}
+
+type validatedConfig struct {
+	Workers int `env:"WORKERS"`
+	Server  struct {
+		TLS validatedTLS
+		Opt *validatedTLS `envPrefix:"OPT_"`
+	}
+	validated bool
+}
+
+func (c *validatedConfig) Validate() error {
+	if c.Workers < 0 {
+		return errors.New("workers count cannot be negative")
+	}
+	c.validated = true
+	return nil
+}
+
+// node is a recursive struct.
+type node struct {
+	Name string `env:"NAME"`
+	Next *node
+}
+
+// tree is a struct recursive through its collection.
+type tree struct {
+	Name     string `env:"NAME"`
+	Children []tree `env:"CHILD"`
+}
+
+// graph is a struct recursive through its map.
+type graph struct {
+	Name  string           `env:"NAME"`
+	Links map[string]graph `env:"LINK"`
+}
+
+// validations counts calls of countedValidation.Validate() method.
+var validations int
+
+type countedValidation struct {
+	V uint `env:"V" default:"1"`
+}
+
+func (c *countedValidation) Validate() error {
+	validations++
+	return nil
+}
+
 type EmbeddedStruct struct {
 	V  bool `env:"EMBEDDED_BOOL"`
 	V2 int  `env:"EMBEDDED_INT"`
@@ -1031,3 +2714,12 @@ func unsetEnv(name string) {
 		panic(err)
 	}
 }
+
+// mapEnv returns env vars lookup function, which uses given map as a source
+// of env vars instead of process environment.
+func mapEnv(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		val, ok := env[name]
+		return val, ok
+	}
+}
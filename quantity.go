@@ -0,0 +1,148 @@
+// Copyright 2017 tyranron
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envigo
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	refl "reflect"
+	"strings"
+)
+
+// ByteSize is a number of bytes, which is parsed from human-friendly byte
+// size or quantity (like `512MiB`, `1.5GB` or `64k`, see parseQuantity).
+type ByteSize uint64
+
+// UnmarshalText parses byte size from given human-friendly representation.
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	q, err := parseQuantity(string(text))
+	if err != nil {
+		return err
+	}
+	if !q.IsInt() || q.Sign() < 0 || !q.Num().IsUint64() {
+		return fmt.Errorf("'%s' is not a valid byte size", text)
+	}
+	*b = ByteSize(q.Num().Uint64())
+	return nil
+}
+
+// quantityUnits maps lowercased unit suffixes of quantities to their
+// multipliers.
+var quantityUnits = func() map[string]*big.Rat {
+	units := map[string]*big.Rat{
+		"":  big.NewRat(1, 1),
+		"b": big.NewRat(1, 1),
+		"n": big.NewRat(1, 1000000000),
+		"u": big.NewRat(1, 1000000),
+	}
+	decimal, binary := big.NewInt(1), big.NewInt(1)
+	for _, prefix := range []string{"k", "m", "g", "t", "p", "e"} {
+		decimal = new(big.Int).Mul(decimal, big.NewInt(1000))
+		binary = new(big.Int).Mul(binary, big.NewInt(1024))
+		units[prefix] = new(big.Rat).SetInt(decimal)
+		units[prefix+"b"] = new(big.Rat).SetInt(decimal)
+		units[prefix+"i"] = new(big.Rat).SetInt(binary)
+		units[prefix+"ib"] = new(big.Rat).SetInt(binary)
+	}
+	return units
+}()
+
+// parseQuantity parses human-friendly byte size or Kubernetes-style quantity,
+// which is a decimal number followed by an optional unit suffix:
+//   - `k`, `M`, `G`, `T`, `P`, `E` (optionally followed by `B`) are decimal
+//     (powers of 1000);
+//   - `Ki`, `Mi`, `Gi`, `Ti`, `Pi`, `Ei` (optionally followed by `B`) are
+//     binary (powers of 1024);
+//   - `m`, `u`, `n` are milli, micro and nano respectively;
+//   - `B` is a byte.
+//
+// Suffixes are case-insensitive, except lone `m` (milli) and `M` (mega).
+func parseQuantity(str string) (*big.Rat, error) {
+	str = strings.TrimSpace(str)
+	end := 0
+	for end < len(str) && strings.IndexByte("0123456789.+-", str[end]) >= 0 {
+		end++
+	}
+	num, unit := str[:end], strings.TrimSpace(str[end:])
+	q, ok := new(big.Rat).SetString(num)
+	if num == "" || !ok {
+		return nil, fmt.Errorf("'%s' is not a valid quantity", str)
+	}
+	if unit == "m" {
+		return q.Mul(q, big.NewRat(1, 1000)), nil
+	}
+	mul, ok := quantityUnits[strings.ToLower(unit)]
+	if !ok {
+		return nil, fmt.Errorf("unknown unit '%s' of quantity", unit)
+	}
+	return q.Mul(q, mul), nil
+}
+
+// parseQuantityInto parses human-friendly byte size or quantity (see
+// parseQuantity) into given settable number.
+// It reports false if given value is not a number.
+func parseQuantityInto(val refl.Value, str string) (bool, error) {
+	switch val.Kind() {
+	case refl.Int, refl.Int8, refl.Int16, refl.Int32, refl.Int64:
+		q, err := parseQuantity(str)
+		if err != nil {
+			return true, err
+		}
+		if !q.IsInt() {
+			return true, errNotInteger
+		}
+		n := q.Num()
+		if !n.IsInt64() || val.OverflowInt(n.Int64()) {
+			return true, errOutOfRange
+		}
+		val.SetInt(n.Int64())
+	case refl.Uint, refl.Uint8, refl.Uint16, refl.Uint32, refl.Uint64:
+		q, err := parseQuantity(str)
+		if err != nil {
+			return true, err
+		}
+		if !q.IsInt() {
+			return true, errNotInteger
+		}
+		n := q.Num()
+		if !n.IsUint64() || val.OverflowUint(n.Uint64()) {
+			return true, errOutOfRange
+		}
+		val.SetUint(n.Uint64())
+	case refl.Float32, refl.Float64:
+		q, err := parseQuantity(str)
+		if err != nil {
+			return true, err
+		}
+		f, _ := q.Float64()
+		if val.OverflowFloat(f) {
+			return true, errOutOfRange
+		}
+		val.SetFloat(f)
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+var (
+	// errNotInteger is an error indicating that quantity is fractional,
+	// while integer is expected.
+	errNotInteger = errors.New("quantity is not an integer")
+	// errOutOfRange is an error indicating that quantity does not fit
+	// into the type of value.
+	errOutOfRange = errors.New("quantity is out of range")
+)
@@ -0,0 +1,72 @@
+// Copyright 2017 tyranron
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envigo
+
+import (
+	"math/big"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestByteSize_UnmarshalText(t *testing.T) {
+	Convey("Parses human-friendly byte sizes", t, func() {
+		for text, expected := range map[string]ByteSize{
+			"1024":   1024,
+			"512MiB": 512 << 20,
+			"512mib": 512 << 20,
+			"1.5GB":  1500000000,
+			"64k":    64000,
+			"64 KiB": 64 << 10,
+			"2Gi":    2 << 30,
+			"10B":    10,
+			"0.5Ki":  512,
+		} {
+			var b ByteSize
+			err := b.UnmarshalText([]byte(text))
+
+			So(err, ShouldBeNil)
+			So(b, ShouldEqual, expected)
+		}
+	})
+
+	Convey("Returns error on invalid byte size", t, func() {
+		for _, text := range []string{
+			"", "MiB", "1.5B", "-1k", "5X", "1.2.3", "16EiB",
+		} {
+			var b ByteSize
+			err := b.UnmarshalText([]byte(text))
+
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+func TestParseQuantity(t *testing.T) {
+	Convey("Parses Kubernetes-style quantities", t, func() {
+		for str, expected := range map[string]*big.Rat{
+			"500m": big.NewRat(1, 2),
+			"2Gi":  big.NewRat(2<<30, 1),
+			"3M":   big.NewRat(3000000, 1),
+			"100u": big.NewRat(1, 10000),
+			"-1.5": big.NewRat(-3, 2),
+		} {
+			q, err := parseQuantity(str)
+
+			So(err, ShouldBeNil)
+			So(q.Cmp(expected), ShouldEqual, 0)
+		}
+	})
+}
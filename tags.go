@@ -0,0 +1,40 @@
+// Copyright 2017 tyranron
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envigo
+
+import (
+	"strings"
+)
+
+// tagOptions represents comma-separated options that follow env var name
+// in struct field tag `env`.
+type tagOptions []string
+
+// parseEnvTag splits value of struct field tag `env` into env var name
+// and its options.
+func parseEnvTag(tag string) (string, tagOptions) {
+	parts := strings.Split(tag, ",")
+	return strings.TrimSpace(parts[0]), tagOptions(parts[1:])
+}
+
+// Has checks whether given option is present in tag options.
+func (o tagOptions) Has(opt string) bool {
+	for _, v := range o {
+		if strings.TrimSpace(v) == opt {
+			return true
+		}
+	}
+	return false
+}
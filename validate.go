@@ -0,0 +1,256 @@
+// Copyright 2017 tyranron
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envigo
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	refl "reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// validate checks parsed value of given struct field against validation
+// rules specified by its tags:
+//   - `nonempty` option requires string or collection to be non-empty
+//     (and any other value to be non-zero);
+//   - `len` tag requires string or collection to have exact length;
+//   - `min` and `max` tags restrict numbers (including time.Duration) by
+//     value, and strings or collections by length;
+//   - `oneof` tag requires value to be one of space-separated values;
+//   - `regexp` tag requires value to match regular expression;
+//   - `url` option requires string to be a valid absolute URL.
+//
+// `oneof`, `regexp` and `url` rules are checked for each element of arrays
+// and slices (and for each value of maps).
+// If value violates any rule, then ValidationError is returned, while
+// malformed rules result in InvalidTagError.
+func (s *session) validate(
+	val refl.Value, field refl.StructField, opts tagOptions,
+	fieldPath, envName string,
+) error {
+	for val.Kind() == refl.Ptr {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+	format := valueFormat{byteSize: opts.Has("bytesize")}
+	invalid := func(rule, format string, args ...interface{}) error {
+		return ValidationError{
+			Field:  fieldPath,
+			EnvVar: envName,
+			Rule:   rule,
+			Err:    fmt.Errorf(format, args...),
+		}
+	}
+	malformed := func(tag string, err error) error {
+		return InvalidTagError{Field: fieldPath, Tag: tag, Err: err}
+	}
+
+	if opts.Has("nonempty") {
+		if hasLen(val) && val.Len() == 0 || !hasLen(val) && val.IsZero() {
+			return invalid("nonempty", "must not be empty")
+		}
+	}
+	if bound, ok := field.Tag.Lookup("len"); ok {
+		n, err := strconv.Atoi(bound)
+		if err != nil {
+			return malformed("len", err)
+		}
+		if !hasLen(val) {
+			return malformed("len", errNotApplicable)
+		}
+		if val.Len() != n {
+			return invalid("len", "length must be %d", n)
+		}
+	}
+	for _, rule := range []string{"min", "max"} {
+		bound, ok := field.Tag.Lookup(rule)
+		if !ok {
+			continue
+		}
+		cmp, err := s.compareTo(val, bound, format)
+		if err != nil {
+			return malformed(rule, err)
+		}
+		what := "value"
+		if hasLen(val) {
+			what = "length"
+		}
+		if rule == "min" && cmp < 0 {
+			return invalid(rule, "%s must be at least %s", what, bound)
+		}
+		if rule == "max" && cmp > 0 {
+			return invalid(rule, "%s must be at most %s", what, bound)
+		}
+	}
+	if tag, ok := field.Tag.Lookup("oneof"); ok {
+		allowed := strings.Fields(tag)
+		for _, elem := range elements(val) {
+			found := false
+			for _, a := range allowed {
+				v := refl.New(elem.Type()).Elem()
+				if err := s.parseScalar(v, a, format); err != nil {
+					return malformed("oneof", err)
+				}
+				if refl.DeepEqual(v.Interface(), elem.Interface()) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return invalid("oneof", "must be one of: %s",
+					strings.Join(allowed, ", "))
+			}
+		}
+	}
+	if tag, ok := field.Tag.Lookup("regexp"); ok {
+		re, err := regexp.Compile(tag)
+		if err != nil {
+			return malformed("regexp", err)
+		}
+		for _, elem := range elements(val) {
+			if !re.MatchString(stringOf(elem)) {
+				return invalid("regexp", "must match regexp '%s'", tag)
+			}
+		}
+	}
+	if opts.Has("url") {
+		for _, elem := range elements(val) {
+			if elem.Kind() != refl.String {
+				return malformed("url", errNotApplicable)
+			}
+			u, err := url.Parse(elem.String())
+			if err != nil || u.Scheme == "" ||
+				(u.Host == "" && u.Opaque == "" && u.Path == "") {
+				return invalid("url", "must be a valid absolute URL")
+			}
+		}
+	}
+	return nil
+}
+
+// errNotApplicable is an error indicating that validation rule cannot be
+// applied to the type of value.
+var errNotApplicable = errors.New("rule is not applicable to field type")
+
+// isCollection checks whether given value is an array, slice or map, which
+// is not parsed as a whole with encoding.TextUnmarshaler.
+func isCollection(val refl.Value) bool {
+	switch val.Kind() {
+	case refl.Array, refl.Slice, refl.Map:
+		return !refl.PtrTo(val.Type()).Implements(textUnmarshalerType)
+	}
+	return false
+}
+
+// hasLen checks whether length of given value is validated instead of
+// the value itself.
+func hasLen(val refl.Value) bool {
+	return val.Kind() == refl.String || isCollection(val)
+}
+
+// elements returns elements of given array or slice (or values of map),
+// dereferencing pointers. Any other value is returned as a single element.
+func elements(val refl.Value) []refl.Value {
+	if !isCollection(val) {
+		return []refl.Value{val}
+	}
+	var elems []refl.Value
+	if val.Kind() == refl.Map {
+		iter := val.MapRange()
+		for iter.Next() {
+			elems = append(elems, elements(iter.Value())...)
+		}
+		return elems
+	}
+	for i := 0; i < val.Len(); i++ {
+		elem := val.Index(i)
+		for elem.Kind() == refl.Ptr && !elem.IsNil() {
+			elem = elem.Elem()
+		}
+		elems = append(elems, elem)
+	}
+	return elems
+}
+
+// stringOf returns string representation of given value.
+func stringOf(val refl.Value) string {
+	if val.Kind() == refl.String {
+		return val.String()
+	}
+	return fmt.Sprint(val.Interface())
+}
+
+// compareTo compares given value (or its length) with given bound,
+// returning -1, 0 or +1 if value is less than, equal to or greater
+// than bound respectively.
+func (s *session) compareTo(
+	val refl.Value, bound string, format valueFormat,
+) (int, error) {
+	if hasLen(val) {
+		n, err := strconv.Atoi(bound)
+		if err != nil {
+			return 0, err
+		}
+		return compare(float64(val.Len()), float64(n)), nil
+	}
+	b := refl.New(val.Type()).Elem()
+	switch val.Kind() {
+	case refl.Int, refl.Int8, refl.Int16, refl.Int32, refl.Int64:
+		if err := s.parseScalar(b, bound, format); err != nil {
+			return 0, err
+		}
+		switch v, b := val.Int(), b.Int(); {
+		case v < b:
+			return -1, nil
+		case v > b:
+			return 1, nil
+		}
+		return 0, nil
+	case refl.Uint, refl.Uint8, refl.Uint16, refl.Uint32, refl.Uint64:
+		if err := s.parseScalar(b, bound, format); err != nil {
+			return 0, err
+		}
+		switch v, b := val.Uint(), b.Uint(); {
+		case v < b:
+			return -1, nil
+		case v > b:
+			return 1, nil
+		}
+		return 0, nil
+	case refl.Float32, refl.Float64:
+		if err := s.parseScalar(b, bound, format); err != nil {
+			return 0, err
+		}
+		return compare(val.Float(), b.Float()), nil
+	}
+	return 0, errNotApplicable
+}
+
+// compare compares given numbers, returning -1, 0 or +1 if a is less than,
+// equal to or greater than b respectively.
+func compare(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}